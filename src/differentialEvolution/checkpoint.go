@@ -0,0 +1,204 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+	"utils"
+)
+
+// CheckpointVersion is increased whenever the checkpoint format changes incompatibly
+const CheckpointVersion = 2
+
+type checkpoint struct {
+	Version               int
+	AgentSize             int
+	PopulationSize        int
+	InitialPopulationSize int
+	Population            [][]float64
+	Fitness               []float64
+	Violation             []float64
+	Parameters            []AgentParameters
+	CurrentGeneration     int
+	Evaluations           int
+	BestAgent             []float64
+	BestFitness           float64
+	BestFitnessHistory    []float64
+	Restarts              []RestartRecord
+	Refinement            RefinementStats
+	Elapsed               time.Duration
+	BestViolation         float64
+	// JSON encoding of the parameter control and constraint handling, holding their adaptive state
+	ParameterControl   []byte
+	ConstraintHandling []byte
+	// state of `RandSource`, so the resumed evolver draws exactly the same numbers the original
+	// one does after saving
+	RandState utils.RandState
+}
+
+// SaveCheckpoint writes the state of the evolution to `w`, without drawing from `Rand`
+// Functions and strategies are not saved, they are given again when resuming
+// `Rand` must have been built with `RandSource`, so its state can be saved
+func (e *Evolver) SaveCheckpoint(w io.Writer) error {
+	if e.Population == nil {
+		return fmt.Errorf("population not initialized")
+	}
+	if e.RandSource == nil {
+		return fmt.Errorf("the state of Rand cannot be saved without its RandSource")
+	}
+	var bestAgent []float64
+	if e.CurrentBestAgent != nil {
+		bestAgent = *e.CurrentBestAgent
+	}
+	controlState, err := json.Marshal(e.ParameterControl)
+	if err != nil {
+		return fmt.Errorf("encoding parameter control: %v", err)
+	}
+	handlingState, err := json.Marshal(e.ConstraintHandling)
+	if err != nil {
+		return fmt.Errorf("encoding constraint handling: %v", err)
+	}
+	c := checkpoint{
+		Version:               CheckpointVersion,
+		AgentSize:             e.AgentSize,
+		PopulationSize:        e.PopulationSize,
+		InitialPopulationSize: e.initialPopulationSize,
+		Population:            e.Population.Items(),
+		Fitness:               e.Fitness,
+		Violation:             e.Violation,
+		Parameters:            e.parameters,
+		CurrentGeneration:     e.CurrentGeneration,
+		Evaluations:           e.Evaluations,
+		BestAgent:             bestAgent,
+		BestFitness:           e.CurrentBestFitness,
+		BestViolation:         e.CurrentBestViolation,
+		BestFitnessHistory:    e.BestFitnessHistory,
+		Restarts:              e.Restarts,
+		Refinement:            e.Refinement,
+		Elapsed:               time.Since(e.StartedAt),
+		ParameterControl:      controlState,
+		ConstraintHandling:    handlingState,
+		RandState:             e.RandSource.State(),
+	}
+	return gob.NewEncoder(w).Encode(c)
+}
+
+// SaveCheckpointFile writes the checkpoint to a temporary file first, so an interrupted
+// save never leaves a corrupt checkpoint behind
+func (e *Evolver) SaveCheckpointFile(filename string) error {
+	tmp := filename + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := e.SaveCheckpoint(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// ResumeEvolver builds an evolver from `p`, then restores the state saved in the checkpoint
+// The parameter control given in `p` must be of the same type as the one used when saving
+// If `p` gives a `Rand`, it must also give the `RandSource` it was built with
+func ResumeEvolver(r io.Reader, p NewEvolverParams) (Evolver, error) {
+	var c checkpoint
+	if err := gob.NewDecoder(r).Decode(&c); err != nil {
+		return Evolver{}, fmt.Errorf("decoding checkpoint: %v", err)
+	}
+	if c.Version != CheckpointVersion {
+		return Evolver{}, fmt.Errorf("unsupported checkpoint version %d, expected %d", c.Version, CheckpointVersion)
+	}
+	if c.AgentSize != p.AgentSize {
+		return Evolver{}, fmt.Errorf("checkpoint agent size %d does not match %d", c.AgentSize, p.AgentSize)
+	}
+	if p.Rand != nil && p.RandSource == nil {
+		return Evolver{}, fmt.Errorf("the state of Rand cannot be restored without its RandSource")
+	}
+	e := NewEvolver(p)
+	if err := restoreState(c.ParameterControl, e.ParameterControl); err != nil {
+		return Evolver{}, fmt.Errorf("decoding parameter control: %v", err)
+	}
+	if err := restoreState(c.ConstraintHandling, e.ConstraintHandling); err != nil {
+		return Evolver{}, fmt.Errorf("decoding constraint handling: %v", err)
+	}
+	population := make(arrays.Array2D, len(c.Population))
+	for i, agent := range c.Population {
+		row := arrays.Array1D(agent)
+		population[i] = &row
+	}
+	e.PopulationSize = c.PopulationSize
+	e.initialPopulationSize = c.InitialPopulationSize
+	e.Population = &population
+	e.Fitness = c.Fitness
+	e.Violation = c.Violation
+	if e.Violation == nil {
+		e.Violation = make(arrays.Array1D, len(c.Fitness))
+	}
+	e.parameters = c.Parameters
+	e.CurrentGeneration = c.CurrentGeneration
+	e.Evaluations = c.Evaluations
+	if c.BestAgent != nil {
+		bestAgent := arrays.Array1D(c.BestAgent)
+		e.CurrentBestAgent = &bestAgent
+	}
+	e.CurrentBestFitness = c.BestFitness
+	e.CurrentBestViolation = c.BestViolation
+	e.BestFitnessHistory = c.BestFitnessHistory
+	e.Restarts = c.Restarts
+	e.Refinement = c.Refinement
+	e.StartedAt = time.Now().Add(-c.Elapsed)
+	e.RandSource.Restore(c.RandState)
+	e.updateStatistics()
+	return e, nil
+}
+
+// decodes the JSON `state` into `v`, if it is a pointer
+func restoreState(state []byte, v interface{}) error {
+	if v == nil || string(state) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(state, v); err != nil {
+		// values that are not pointers cannot hold any state to restore
+		if _, ok := err.(*json.InvalidUnmarshalError); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func ResumeEvolverFromFile(filename string, p NewEvolverParams) (Evolver, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Evolver{}, err
+	}
+	defer f.Close()
+	return ResumeEvolver(f, p)
+}
+
+// NewCheckpointer is an observer that saves `e` to `filename` every `interval` generations,
+// or every generation if `interval` is less than 1
+// Failed saves are logged, and the evolution goes on
+func NewCheckpointer(e *Evolver, filename string, interval int) Observer {
+	if interval < 1 {
+		interval = 1
+	}
+	return ObserverFuncs{
+		OnGenerationEnded: func(s Snapshot) {
+			if s.Generation%interval != 0 {
+				return
+			}
+			if err := e.SaveCheckpointFile(filename); err != nil {
+				log.Printf("Checkpoint failed: %v", err)
+			}
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"utils"
+)
+
+// Rastrigin function, which has many local minima and keeps the population busy
+func rastrigin(agent *arrays.Array1D) float64 {
+	sum := 10 * float64(agent.Length())
+	for _, x := range *agent {
+		sum += x*x - 10*math.Cos(2*math.Pi*x)
+	}
+	return sum
+}
+
+// parameters of a seeded run with adaptive parameters on several workers
+func seededParams(seed int64, maxGenerations int) NewEvolverParams {
+	return NewEvolverParams{
+		AgentSize:        5,
+		PopulationSize:   20,
+		SearchSpace:      []utils.Range1D{{LowerBound: -5, UpperBound: 5}},
+		CrossoverRate:    0.9,
+		WeightingFactor:  0.5,
+		MaxGenerations:   maxGenerations,
+		FitnessFunction:  rastrigin,
+		ParameterControl: NewSHADE(5),
+		Workers:          4,
+		RandSource:       utils.NewCountingSource(seed),
+	}
+}
+
+func runToEnd(t *testing.T, e *Evolver) Result {
+	t.Helper()
+	result, err := e.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestResumeFromCheckpoint(t *testing.T) {
+	uninterrupted := NewEvolver(seededParams(1, 60))
+	expected := runToEnd(t, &uninterrupted)
+
+	interrupted := NewEvolver(seededParams(1, 30))
+	runToEnd(t, &interrupted)
+	var buffer bytes.Buffer
+	if err := interrupted.SaveCheckpoint(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := ResumeEvolver(&buffer, seededParams(2, 60))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := runToEnd(t, &resumed)
+	if !reflect.DeepEqual(result.BestAgent, expected.BestAgent) || result.BestFitness != expected.BestFitness ||
+		result.Evaluations != expected.Evaluations || result.Generations != expected.Generations {
+		t.Errorf("resumed run ends with %v (%v, %d evaluations), expected %v (%v, %d evaluations)",
+			result.BestAgent, result.BestFitness, result.Evaluations, expected.BestAgent, expected.BestFitness, expected.Evaluations)
+	}
+	if !reflect.DeepEqual(resumed.Population, uninterrupted.Population) {
+		t.Errorf("resumed run ends with a different population")
+	}
+}
+
+func TestCheckpointerDoesNotChangeTheRun(t *testing.T) {
+	plain := NewEvolver(seededParams(1, 40))
+	expected := runToEnd(t, &plain)
+
+	checkpointed := NewEvolver(seededParams(1, 40))
+	checkpointed.Observers = append(checkpointed.Observers, NewCheckpointer(&checkpointed, filepath.Join(t.TempDir(), "checkpoint"), 3))
+	result := runToEnd(t, &checkpointed)
+	if !reflect.DeepEqual(result.BestAgent, expected.BestAgent) || result.BestFitness != expected.BestFitness {
+		t.Errorf("checkpointed run ends with %v (%v), expected %v (%v)", result.BestAgent, result.BestFitness, expected.BestAgent, expected.BestFitness)
+	}
+}
+
+func TestResumeRejectsOtherVersions(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(checkpoint{Version: CheckpointVersion - 1, AgentSize: 5}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ResumeEvolver(&buffer, seededParams(1, 10))
+	if err == nil || !strings.Contains(err.Error(), "unsupported checkpoint version") {
+		t.Errorf("got error %v, expected an unsupported version", err)
+	}
+}
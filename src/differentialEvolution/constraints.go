@@ -0,0 +1,163 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"math"
+	"sort"
+)
+
+// ConstraintFunction returns how much `agent` violates a constraint, 0 or less if it satisfies it
+// e.g. the distance the end effector goes below a table plane
+type ConstraintFunction func(agent *arrays.Array1D) float64
+
+// ConstraintFunctionsFactory builds the constraint functions of a single worker,
+// like `FitnessFunctionFactory` does for the fitness function
+type ConstraintFunctionsFactory func() []ConstraintFunction
+
+// ConstraintHandler decides which of two agents is preferred, given their fitness and
+// total constraint violation
+type ConstraintHandler interface {
+	// Better tells whether an agent with fitness `fa` and violation `va` is strictly preferred
+	// to one with fitness `fb` and violation `vb`
+	Better(fa, va, fb, vb float64) bool
+	// Update is called once the initial population is evaluated, then after each generation
+	Update(e *Evolver)
+}
+
+// DebRules prefers feasible agents to infeasible ones, feasible agents with a lower fitness,
+// and infeasible agents with a lower violation (Deb, 2000)
+type DebRules struct{}
+
+func (DebRules) Better(fa, va, fb, vb float64) bool {
+	switch {
+	case va <= 0 && vb <= 0:
+		return fa < fb
+	case va <= 0 || vb <= 0:
+		return va <= 0
+	}
+	return va < vb
+}
+
+func (DebRules) Update(e *Evolver) {}
+
+// AdaptivePenalty compares agents by their fitness plus `Penalty` times their violation
+// The penalty is multiplied by `Increase` if the best agent was infeasible during each of the last
+// `Period` generations, and divided by `Decrease` if it was feasible during each of them
+// (Hadj-Alouane and Bean, 1997)
+type AdaptivePenalty struct {
+	Penalty         float64
+	Increase        float64
+	Decrease        float64
+	Period          int
+	FeasibleHistory []bool // feasibility of the best agent in the last generations
+}
+
+func NewAdaptivePenalty() *AdaptivePenalty {
+	return &AdaptivePenalty{
+		Penalty:  1,
+		Increase: 2,
+		Decrease: 1.5,
+		Period:   5,
+	}
+}
+
+func (p *AdaptivePenalty) Better(fa, va, fb, vb float64) bool {
+	return fa+p.Penalty*math.Max(0, va) < fb+p.Penalty*math.Max(0, vb)
+}
+
+func (p *AdaptivePenalty) Update(e *Evolver) {
+	p.FeasibleHistory = append(p.FeasibleHistory, e.CurrentBestViolation <= 0)
+	if len(p.FeasibleHistory) < p.Period {
+		return
+	}
+	allFeasible, allInfeasible := true, true
+	for _, feasible := range p.FeasibleHistory {
+		allFeasible = allFeasible && feasible
+		allInfeasible = allInfeasible && !feasible
+	}
+	if allInfeasible {
+		p.Penalty *= p.Increase
+	} else if allFeasible {
+		p.Penalty /= p.Decrease
+	}
+	p.FeasibleHistory = p.FeasibleHistory[1:]
+}
+
+// EpsilonConstraint compares agents whose violations are both within `Epsilon` by their fitness,
+// and other agents by their violation (Takahama and Sakai, 2006)
+// `Epsilon` starts at the violation of the agent at `Fraction` of the initial population ranked by
+// violation, and shrinks to 0 over `ControlGenerations` generations as (1 - t/Tc)^`Exponent`
+type EpsilonConstraint struct {
+	ControlGenerations int
+	Exponent           float64
+	Fraction           float64
+	InitialEpsilon     float64
+	Epsilon            float64
+}
+
+func NewEpsilonConstraint(controlGenerations int) *EpsilonConstraint {
+	return &EpsilonConstraint{
+		ControlGenerations: controlGenerations,
+		Exponent:           5,
+		Fraction:           0.2,
+	}
+}
+
+func (c *EpsilonConstraint) Better(fa, va, fb, vb float64) bool {
+	va, vb = math.Max(0, va), math.Max(0, vb)
+	if (va <= c.Epsilon && vb <= c.Epsilon) || va == vb {
+		return fa < fb
+	}
+	return va < vb
+}
+
+func (c *EpsilonConstraint) Update(e *Evolver) {
+	if e.CurrentGeneration == 0 {
+		violations := *e.Violation.Copy()
+		sort.Float64s(violations)
+		c.InitialEpsilon = violations[int(c.Fraction*float64(len(violations)-1))]
+	}
+	if e.CurrentGeneration >= c.ControlGenerations {
+		c.Epsilon = 0
+		return
+	}
+	c.Epsilon = c.InitialEpsilon * math.Pow(1-float64(e.CurrentGeneration)/float64(c.ControlGenerations), c.Exponent)
+}
+
+func (e *Evolver) buildConstraintFunctions() {
+	e.constraintFunctions = make([][]ConstraintFunction, e.Workers)
+	for i := range e.constraintFunctions {
+		if e.ConstraintsFactory != nil {
+			e.constraintFunctions[i] = e.ConstraintsFactory()
+		} else {
+			e.constraintFunctions[i] = e.Constraints
+		}
+	}
+}
+
+// fitness and total constraint violation of `agent`, using the functions of `worker`
+func (e *Evolver) evaluate(worker int, agent *arrays.Array1D) (float64, float64) {
+	return e.fitnessFunctions[worker](agent), e.violation(worker, agent)
+}
+
+// total violation of the constraints of `worker` by `agent`, without evaluating its fitness
+func (e *Evolver) violation(worker int, agent *arrays.Array1D) float64 {
+	violation := 0.0
+	for _, constraint := range e.constraintFunctions[worker] {
+		violation += math.Max(0, constraint(agent))
+	}
+	return violation
+}
+
+// whether an agent with fitness `fa` and violation `va` is strictly preferred to one with `fb` and `vb`
+func (e *Evolver) better(fa, va, fb, vb float64) bool {
+	if e.ConstraintHandling == nil {
+		return fa < fb
+	}
+	return e.ConstraintHandling.Better(fa, va, fb, vb)
+}
+
+// whether an agent is preferred to the best agent found so far, or ties with it
+func (e *Evolver) isNewBest(fitness, violation float64) bool {
+	return !e.better(e.CurrentBestFitness, e.CurrentBestViolation, fitness, violation)
+}
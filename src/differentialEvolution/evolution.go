@@ -2,10 +2,14 @@ package differentialEvolution
 
 import (
 	"arrays"
+	"context"
 	"fmt"
 	"log"
 	"math"
 	"math/rand"
+	"runtime"
+	"sort"
+	"time"
 	"utils"
 )
 
@@ -14,28 +18,92 @@ type AgentFitnessPair struct {
 	Fitness float64
 }
 
+// agent along with its total constraint violation
+type evaluatedAgent struct {
+	AgentFitnessPair
+	violation float64
+}
+
+// outcome of an agent's selection, along with the parameters the surviving agent carries
+type trialResult struct {
+	evaluatedAgent
+	parameters AgentParameters
+	success    *ParameterSuccess // nil if the trial did not improve on the reference agent
+}
+
 // evolution optimizes fitness to 0
 type FitnessFunction func(agent *arrays.Array1D) float64
 
 type Evolver struct {
 	// init factors
-	AgentSize       int
-	PopulationSize  int
-	CrossoverRate   float64
-	WeightingFactor float64
-	SearchSpace     []utils.Range1D
+	AgentSize        int
+	PopulationSize   int
+	CrossoverRate    float64
+	WeightingFactor  float64
+	SearchSpace      []utils.Range1D
+	Mutation         MutationStrategy
+	ParameterControl ParameterControl
+	Initialization   Initializer
+	// linear population size reduction, enabled when `MinPopulationSize` is greater than 0
+	MinPopulationSize     int
+	initialPopulationSize int
+	// constraints of the problem, their total violation for each agent being compared by `ConstraintHandling`
+	Constraints         []ConstraintFunction
+	ConstraintsFactory  ConstraintFunctionsFactory
+	ConstraintHandling  ConstraintHandler
+	constraintFunctions [][]ConstraintFunction
 	// termination criteria
+	// if `Termination` is nil, the evolution stops on any of the other ones that is set
+	Termination    TerminationCriterion
 	MaxGenerations int
+	MaxEvaluations int
 	TargetFitness  float64
 	StallPeriod    int
 	StallFactor    float64
-	stallCount     int
+	StopReason     StopReason
+	StartedAt      time.Time
+	// if set, the evolution restarts instead of stopping when it stalls or its diversity collapses,
+	// at most `MaxRestarts` times if greater than 0
+	Restart     RestartPolicy
+	MaxRestarts int
+	Restarts    []RestartRecord
+	// local refinement with `LocalSearch` of the best agent when the evolution ends, if `RefineFinalBest`,
+	// and of the `RefineElites` fittest agents every `RefinementInterval` generations, if greater than 0
+	LocalSearch        LocalSearch
+	RefineFinalBest    bool
+	RefinementInterval int
+	RefineElites       int
+	Refinement         RefinementStats
 
 	CurrentGeneration  int
 	CurrentBestFitness float64
 	CurrentBestAgent   *arrays.Array1D
-	Population         *arrays.Array2D
-	FitnessFunction    FitnessFunction
+	// total constraint violation of `CurrentBestAgent`, 0 if it is feasible
+	CurrentBestViolation float64
+	BestFitnessHistory   []float64 // best fitness at the start of the evolution, then after each generation
+	Statistics           Statistics
+	Population           *arrays.Array2D
+	// fitness of each agent in `Population`, so only trial vectors need to be evaluated
+	Fitness arrays.Array1D
+	// total constraint violation of each agent in `Population`
+	Violation arrays.Array1D
+	// number of times the fitness function has been called
+	Evaluations     int
+	FitnessFunction FitnessFunction
+	// fitness evaluations run on `Workers` goroutines, each with its own function
+	// built by `FitnessFunctionFactory`, or sharing `FitnessFunction` if there is no factory
+	Workers                int
+	FitnessFunctionFactory FitnessFunctionFactory
+	fitnessFunctions       []FitnessFunction
+	Observers              []Observer
+	// every random draw of the evolver comes from `Rand`, or from streams derived from it,
+	// so runs are reproducible from its seed
+	Rand *rand.Rand
+	// source of `Rand`, if known, which lets checkpoints save its state
+	RandSource *utils.CountingSource
+	// control parameters of each agent in `Population`, and agent numbers sorted from best to worst fitness
+	parameters []AgentParameters
+	ranking    []int
 }
 
 type NewEvolverParams struct {
@@ -44,11 +112,46 @@ type NewEvolverParams struct {
 	CrossoverRate   float64
 	WeightingFactor float64
 	SearchSpace     []utils.Range1D
-	MaxGenerations  int
-	TargetFitness   float64
-	StallPeriod     int
-	StallFactor     float64
-	FitnessFunction FitnessFunction
+	Mutation        MutationStrategy // defaults to `RandOne`
+	// defaults to `FixedParameters`, which uses `WeightingFactor` and `CrossoverRate` throughout
+	// self-adaptive controls use them only as the initial parameters of each agent
+	ParameterControl ParameterControl
+	// defaults to `UniformInitializer`
+	Initialization Initializer
+	// if greater than 0, `PopulationSize` shrinks linearly down to `MinPopulationSize`
+	// as `MaxEvaluations` get used, dropping the worst agents (L-SHADE)
+	MinPopulationSize int
+	// defaults to stopping on any of the criteria given by the fields below
+	Termination    TerminationCriterion
+	MaxGenerations int
+	MaxEvaluations int // only checked if greater than 0
+	TargetFitness  float64
+	StallPeriod    int
+	StallFactor    float64
+	// restarts instead of stopping on `FitnessStalled` or `DiversityCollapsed`, up to `MaxRestarts` times if greater than 0
+	Restart     RestartPolicy
+	MaxRestarts int
+	// local refinement, run on the final best agent if `RefineFinalBest`, and on the `RefineElites`
+	// (defaults to 1) fittest agents every `RefinementInterval` generations if greater than 0
+	LocalSearch        LocalSearch
+	RefineFinalBest    bool
+	RefinementInterval int
+	RefineElites       int
+	// if `ConstraintsFactory` is nil, `Constraints` are shared by all workers and must be safe for
+	// concurrent use; `ConstraintHandling` defaults to `DebRules` if there are constraints
+	Constraints        []ConstraintFunction
+	ConstraintsFactory ConstraintFunctionsFactory
+	ConstraintHandling ConstraintHandler
+	// if `FitnessFunctionFactory` is nil, `FitnessFunction` is shared by all workers
+	// and must be safe for concurrent use
+	FitnessFunction        FitnessFunction
+	FitnessFunctionFactory FitnessFunctionFactory
+	Workers                int // defaults to the number of CPUs
+	Observers              []Observer
+	Rand                   *rand.Rand // defaults to a source seeded with the current time
+	// source `Rand` was built with, required to save checkpoints, see `utils.NewCountingRand`
+	// `Rand` defaults to one built with it, if given
+	RandSource *utils.CountingSource
 }
 
 func NewEvolver(p NewEvolverParams) Evolver {
@@ -61,144 +164,392 @@ func NewEvolver(p NewEvolverParams) Evolver {
 			p.SearchSpace = append(p.SearchSpace, p.SearchSpace[0])
 		}
 	}
-	return Evolver{
-		AgentSize:          p.AgentSize,
-		PopulationSize:     p.PopulationSize,
-		CrossoverRate:      p.CrossoverRate,
-		WeightingFactor:    p.WeightingFactor,
-		SearchSpace:        p.SearchSpace,
-		MaxGenerations:     p.MaxGenerations,
-		TargetFitness:      p.TargetFitness,
-		StallPeriod:        p.StallPeriod,
-		StallFactor:        p.StallFactor,
-		CurrentGeneration:  0,
-		CurrentBestFitness: math.Inf(1),
-		CurrentBestAgent:   nil,
-		Population:         nil,
-		FitnessFunction:    p.FitnessFunction,
+	for j, space := range p.SearchSpace {
+		if err := space.Validate(); err != nil {
+			log.Fatalf("Invalid search space for feature %d: %v", j, err)
+		}
+	}
+	if p.Mutation == nil {
+		p.Mutation = RandOne{}
+	}
+	if p.ParameterControl == nil {
+		p.ParameterControl = FixedParameters{}
+	}
+	if p.Initialization == nil {
+		p.Initialization = UniformInitializer{}
+	}
+	if err := ValidateInitializer(p.Initialization, p.SearchSpace); err != nil {
+		log.Fatalf("Invalid initialization: %v", err)
+	}
+	if p.ConstraintHandling == nil && (len(p.Constraints) > 0 || p.ConstraintsFactory != nil) {
+		p.ConstraintHandling = DebRules{}
+	}
+	if p.RefineElites <= 0 {
+		p.RefineElites = 1
+	}
+	if p.LocalSearch == nil && (p.RefineFinalBest || p.RefinementInterval > 0) {
+		log.Fatalf("Refinement requires a local search")
+	}
+	if r, ok := p.Restart.(PartialRestart); ok && (r.Fraction <= 0 || r.Fraction > 1) {
+		log.Fatalf("Partial restart fraction %v outside of (0, 1]", r.Fraction)
+	}
+	if p.Workers <= 0 {
+		p.Workers = runtime.NumCPU()
+	}
+	if p.Rand == nil {
+		if p.RandSource == nil {
+			p.RandSource = utils.NewCountingSource(time.Now().UnixNano())
+		}
+		p.Rand = rand.New(p.RandSource)
+	}
+	minPopulationSize := p.PopulationSize
+	if p.MinPopulationSize > 0 {
+		if p.MaxEvaluations <= 0 {
+			log.Fatalf("Population size reduction requires max evaluations")
+		}
+		if p.MinPopulationSize > p.PopulationSize {
+			log.Fatalf("Min population size greater than population size")
+		}
+		minPopulationSize = p.MinPopulationSize
+	}
+	if minPopulationSize <= p.Mutation.RandomAgents() {
+		log.Fatalf("Population size must be greater than %d for the chosen mutation strategy", p.Mutation.RandomAgents())
+	}
+	e := Evolver{
+		AgentSize:              p.AgentSize,
+		PopulationSize:         p.PopulationSize,
+		CrossoverRate:          p.CrossoverRate,
+		WeightingFactor:        p.WeightingFactor,
+		SearchSpace:            p.SearchSpace,
+		Mutation:               p.Mutation,
+		ParameterControl:       p.ParameterControl,
+		Initialization:         p.Initialization,
+		MinPopulationSize:      p.MinPopulationSize,
+		initialPopulationSize:  p.PopulationSize,
+		Termination:            p.Termination,
+		MaxGenerations:         p.MaxGenerations,
+		MaxEvaluations:         p.MaxEvaluations,
+		TargetFitness:          p.TargetFitness,
+		StallPeriod:            p.StallPeriod,
+		StallFactor:            p.StallFactor,
+		Restart:                p.Restart,
+		MaxRestarts:            p.MaxRestarts,
+		LocalSearch:            p.LocalSearch,
+		RefineFinalBest:        p.RefineFinalBest,
+		RefinementInterval:     p.RefinementInterval,
+		RefineElites:           p.RefineElites,
+		CurrentGeneration:      0,
+		CurrentBestFitness:     math.Inf(1),
+		CurrentBestAgent:       nil,
+		CurrentBestViolation:   math.Inf(1),
+		Constraints:            p.Constraints,
+		ConstraintsFactory:     p.ConstraintsFactory,
+		ConstraintHandling:     p.ConstraintHandling,
+		Population:             nil,
+		FitnessFunction:        p.FitnessFunction,
+		Rand:                   p.Rand,
+		RandSource:             p.RandSource,
+		Workers:                p.Workers,
+		FitnessFunctionFactory: p.FitnessFunctionFactory,
+		Observers:              p.Observers,
 	}
+	e.buildFitnessFunctions()
+	e.buildConstraintFunctions()
+	return e
 }
 
 func (e *Evolver) InitializePopulation() {
-	e.Population = &arrays.Array2D{}
-	for i := 0; i < e.PopulationSize; i++ {
-		agent := &arrays.Array1D{}
-		for j := 0; j < e.AgentSize; j++ {
-			agent.Append(utils.RandomInRange(e.SearchSpace[j]))
+	e.initializePopulation(context.Background())
+}
+
+// if `ctx` ends midway, agents left unevaluated get an infinite fitness and violation
+func (e *Evolver) initializePopulation(ctx context.Context) {
+	e.Population = e.Initialization.Initialize(e.initialPopulationSize, e.SearchSpace, e.Rand)
+	if e.Population.NRows() < e.initialPopulationSize {
+		log.Fatalf("Initialization returned %d agents, expected at least %d", e.Population.NRows(), e.initialPopulationSize)
+	}
+	// every candidate is evaluated, then only the fittest are kept
+	e.PopulationSize = e.Population.NRows()
+	e.parameters = make([]AgentParameters, e.PopulationSize)
+	for i := range e.parameters {
+		e.parameters[i] = e.initialParameters()
+	}
+	e.evaluatePopulation(ctx)
+	if e.PopulationSize > e.initialPopulationSize {
+		e.keepBest(e.initialPopulationSize)
+	}
+	if e.ConstraintHandling != nil {
+		e.ConstraintHandling.Update(e)
+	}
+	e.BestFitnessHistory = []float64{e.CurrentBestFitness}
+	e.StopReason = NotStopped
+	e.StartedAt = time.Now()
+}
+
+// agent sampled uniformly from the search space
+func (e *Evolver) randomAgent() *arrays.Array1D {
+	agent := &arrays.Array1D{}
+	for j := 0; j < e.AgentSize; j++ {
+		agent.Append(utils.RandomInRange(e.Rand, e.SearchSpace[j]))
+	}
+	return agent
+}
+
+func (e *Evolver) initialParameters() AgentParameters {
+	return AgentParameters{
+		WeightingFactor: e.WeightingFactor,
+		CrossoverRate:   e.CrossoverRate,
+	}
+}
+
+func (e *Evolver) evaluatePopulation(ctx context.Context) {
+	e.Fitness = make(arrays.Array1D, e.PopulationSize)
+	e.Violation = make(arrays.Array1D, e.PopulationSize)
+	agentNumbers := make([]int, e.PopulationSize)
+	for i := range agentNumbers {
+		agentNumbers[i] = i
+	}
+	e.evaluateAgents(ctx, agentNumbers)
+}
+
+// evaluates the given agents of the population, updating their fitness and the best agent
+// If `ctx` ends midway, agents left unevaluated get an infinite fitness and violation, so they
+// are never preferred to evaluated ones
+func (e *Evolver) evaluateAgents(ctx context.Context, agentNumbers []int) {
+	evaluated := make([]bool, len(agentNumbers))
+	e.forEachAgent(ctx, len(agentNumbers), func(worker, i int) {
+		n := agentNumbers[i]
+		fitness, violation := e.evaluate(worker, e.Population.GetRow(n))
+		e.Fitness.Set(n, fitness)
+		e.Violation.Set(n, violation)
+		evaluated[i] = true
+	})
+	lastBestFitness, lastBestViolation := e.CurrentBestFitness, e.CurrentBestViolation
+	for i, n := range agentNumbers {
+		if !evaluated[i] {
+			e.Fitness.Set(n, math.Inf(1))
+			e.Violation.Set(n, math.Inf(1))
+			continue
 		}
-		e.Population.Append(agent)
+		e.Evaluations++
+		e.offerBest(e.Population.GetRow(n), e.Fitness.Get(n), e.Violation.Get(n))
 	}
+	e.updateStatistics()
+	e.notifyIfImproved(lastBestFitness, lastBestViolation)
 }
 
-// Default termination criterion is `TargetFitness` equals to 0
-// If `MaxGenerations` is set to a number greater than 0, it is checked first
-func (e *Evolver) ShouldContinue() bool {
-	if e.MaxGenerations > 0 && e.CurrentGeneration == e.MaxGenerations {
-		log.Print("Max generations reached.")
-		return false
+// makes `agent` the best agent found so far if it is preferred to it, or ties with it
+func (e *Evolver) offerBest(agent *arrays.Array1D, fitness, violation float64) {
+	if e.isNewBest(fitness, violation) {
+		e.CurrentBestFitness = fitness
+		e.CurrentBestViolation = violation
+		e.CurrentBestAgent = agent.Copy()
 	}
-	if e.TargetFitness >= 0 && e.CurrentBestFitness <= e.TargetFitness {
-		log.Print("Target fitness reached.")
-		return false
+}
+
+func (e *Evolver) notifyIfImproved(lastBestFitness, lastBestViolation float64) {
+	if e.better(e.CurrentBestFitness, e.CurrentBestViolation, lastBestFitness, lastBestViolation) {
+		e.notify(Observer.NewBest)
+	}
+}
+
+// sorts agent numbers from best to worst, as compared by `better`
+func (e *Evolver) rankPopulation() {
+	e.ranking = make([]int, e.PopulationSize)
+	for i := range e.ranking {
+		e.ranking[i] = i
+	}
+	sort.SliceStable(e.ranking, func(i, j int) bool {
+		a, b := e.ranking[i], e.ranking[j]
+		return e.better(e.Fitness.Get(a), e.Violation.Get(a), e.Fitness.Get(b), e.Violation.Get(b))
+	})
+}
+
+// ShouldContinue checks the termination criterion, recording in `StopReason` why the evolution stopped
+func (e *Evolver) ShouldContinue() bool {
+	return e.shouldContinue(context.Background())
+}
+
+// a restart instead of stopping ends early if `ctx` does
+func (e *Evolver) shouldContinue(ctx context.Context) bool {
+	termination := e.Termination
+	if termination == nil {
+		termination = e.defaultTermination()
 	}
-	// if fitness varies in a proportion less than `StallFactor` to the last fitness
-	// for `StallPeriod` times, evolution halts
-	if e.StallPeriod > 0 && e.stallCount >= e.StallPeriod {
-		log.Print("Evolution stalled.")
+	if reason := termination.Check(e); reason != NotStopped {
+		if e.shouldRestart(reason) {
+			e.restart(ctx, reason)
+			return true
+		}
+		e.stop(reason)
 		return false
 	}
 	return true
 }
 
-func (e *Evolver) pickRandomAgents(exclude int) (arrays.Array1D, arrays.Array1D, arrays.Array1D) {
-	howMany := 3
-	agentNumbers := utils.PickRandom(e.PopulationSize, howMany, exclude)
-	agents := arrays.Array2D{}
-	for _, n := range *agentNumbers {
-		agent := e.Population.GetRow(n).Copy()
-		agents.Append(agent)
+func (e *Evolver) pickRandomAgents(rng *rand.Rand, exclude, howMany int) []*arrays.Array1D {
+	agentNumbers := utils.PickRandom(rng, e.PopulationSize, howMany, exclude)
+	agents := make([]*arrays.Array1D, howMany)
+	for i, n := range *agentNumbers {
+		agents[i] = e.Population.GetRow(n).Copy()
 	}
-	return *agents[0], *agents[1], *agents[2]
+	return agents
+}
+
+func (e *Evolver) bestAgent() *arrays.Array1D {
+	return e.Population.GetRow(e.ranking[0]).Copy()
+}
+
+// picks one of the `top` best agents at random
+func (e *Evolver) pickRandomTopAgent(rng *rand.Rand, top int) *arrays.Array1D {
+	top = int(math.Max(1, math.Min(float64(top), float64(e.PopulationSize))))
+	return e.Population.GetRow(e.ranking[rng.Intn(top)]).Copy()
 }
 
-func (e *Evolver) mutate(referenceAgentNumber int) *arrays.Array1D {
-	r1, r2, r3 := e.pickRandomAgents(referenceAgentNumber)
-	// Compute v = r1 + F*(r3 - r2)
-	// in which
-	//     `v` is the mutated value
-	//     `ri` is the i-th random agent from the population
-	//     `F` is the weighting factor
-	mutated := r1.Add((r3.Subtract(&r2)).MultiplyByConstant(e.WeightingFactor))
-	// ensure the mutated values are within the search space
+func (e *Evolver) mutate(referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D {
+	mutated := e.Mutation.Mutate(e, referenceAgentNumber, weightingFactor, rng)
+	referenceAgent := e.Population.GetRow(referenceAgentNumber)
+	// ensure the mutated values are within the search space, following the policy of each range
 	for i, value := range mutated.Items() {
-		mutated.Set(i, utils.ConstrainValue(value, e.SearchSpace[i]))
+		mutated.Set(i, mutatedGene(rng, value, referenceAgent.Get(i), e.SearchSpace[i]))
 	}
 	return mutated
 }
 
-func (e *Evolver) crossover(referenceAgent, mutatedAgent *arrays.Array1D) *arrays.Array1D {
+// brings a gene computed by a mutation strategy to a value its range admits
+// Categories have no order, so differences between them mean nothing: a categorical gene keeps
+// the computed value only if it is a category, as when the difference vectors agree on it,
+// and is otherwise drawn at random
+func mutatedGene(rng *rand.Rand, value, parent float64, space utils.Range1D) float64 {
+	if space.Kind == utils.Categorical {
+		if utils.InRange(value, space) && value == math.Trunc(value) {
+			return value
+		}
+		return utils.RandomInRange(rng, space)
+	}
+	return utils.HandleBounds(rng, value, parent, space)
+}
+
+func (e *Evolver) crossover(referenceAgent, mutatedAgent *arrays.Array1D, crossoverRate float64, rng *rand.Rand) *arrays.Array1D {
 	crossed := referenceAgent.Copy()
-	randomIndex := rand.Intn(e.AgentSize) // random index so at least one feature gets crossed
+	randomIndex := rng.Intn(e.AgentSize) // random index so at least one feature gets crossed
 	for i := range referenceAgent.Items() {
-		ri := rand.Float64()
-		if ri <= e.CrossoverRate || i == randomIndex {
+		ri := rng.Float64()
+		if ri <= crossoverRate || i == randomIndex {
 			crossed.Set(i, mutatedAgent.Get(i))
 		} // else keep reference agent value
 	}
 	return crossed
 }
 
-func (e *Evolver) mutateAndCrossover(referenceAgentNumber int) *arrays.Array1D {
+func (e *Evolver) mutateAndCrossover(referenceAgentNumber int, parameters AgentParameters, rng *rand.Rand) *arrays.Array1D {
 	referenceAgent := e.Population.GetRow(referenceAgentNumber).Copy()
-	mutated := e.mutate(referenceAgentNumber)
-	crossed := e.crossover(referenceAgent, mutated)
+	mutated := e.mutate(referenceAgentNumber, parameters.WeightingFactor, rng)
+	crossed := e.crossover(referenceAgent, mutated, parameters.CrossoverRate, rng)
 	return crossed
 }
 
-func (e *Evolver) tryReplaceAgent(referenceAgentNumber int, resultChannel chan AgentFitnessPair) {
+func (e *Evolver) tryReplaceAgent(referenceAgentNumber int, parameters AgentParameters, rng *rand.Rand, worker int) trialResult {
 	referenceAgent := e.Population.GetRow(referenceAgentNumber).Copy()
-	crossed := e.mutateAndCrossover(referenceAgentNumber)
+	crossed := e.mutateAndCrossover(referenceAgentNumber, parameters, rng)
 
-	referenceFitness := e.FitnessFunction(referenceAgent)
-	crossedFitness := e.FitnessFunction(crossed)
+	referenceFitness := e.Fitness.Get(referenceAgentNumber)
+	referenceViolation := e.Violation.Get(referenceAgentNumber)
+	crossedFitness, crossedViolation := e.evaluate(worker, crossed)
 
-	var result AgentFitnessPair
-	if crossedFitness <= referenceFitness {
-		result = AgentFitnessPair{crossed, crossedFitness}
+	var result trialResult
+	if !e.better(referenceFitness, referenceViolation, crossedFitness, crossedViolation) {
+		result.evaluatedAgent = evaluatedAgent{AgentFitnessPair{crossed, crossedFitness}, crossedViolation}
+		result.parameters = parameters
+		if e.better(crossedFitness, crossedViolation, referenceFitness, referenceViolation) {
+			// a trial may be preferred for its lower violation despite a higher fitness
+			improvement := math.Max(referenceFitness-crossedFitness, referenceViolation-crossedViolation)
+			result.success = &ParameterSuccess{parameters, improvement}
+		}
 	} else {
-		result = AgentFitnessPair{referenceAgent, referenceFitness}
+		result.evaluatedAgent = evaluatedAgent{AgentFitnessPair{referenceAgent, referenceFitness}, referenceViolation}
+		result.parameters = e.parameters[referenceAgentNumber]
 	}
-	resultChannel <- result
+	return result
 }
 
 func (e *Evolver) Evolve() error {
+	return e.evolve(context.Background())
+}
+
+// evolves one generation; if `ctx` ends midway, agents whose trials were not evaluated are kept as they are
+func (e *Evolver) evolve(ctx context.Context) error {
 	if e.Population == nil {
 		return fmt.Errorf("population not initialized")
 	}
+	if e.Fitness == nil {
+		e.evaluatePopulation(ctx)
+	}
+	e.rankPopulation()
+	e.notify(Observer.GenerationStarted)
+	lastBestFitness, lastBestViolation := e.CurrentBestFitness, e.CurrentBestViolation
 	newPopulation := make(arrays.Array2D, e.PopulationSize)
-	newPopulationChannel := make(chan AgentFitnessPair)
-	lastBestFitness := e.CurrentBestFitness
+	newFitness := make(arrays.Array1D, e.PopulationSize)
+	newViolation := make(arrays.Array1D, e.PopulationSize)
+	newParameters := make([]AgentParameters, e.PopulationSize)
+	var successes []ParameterSuccess
 
+	// parameters and random streams are drawn in agent order, so the order
+	// workers pick up agents does not change the outcome of a seeded run
+	parameters := make([]AgentParameters, e.PopulationSize)
+	rngs := make([]*rand.Rand, e.PopulationSize)
 	for i := range e.Population.Items() {
-		go e.tryReplaceAgent(i, newPopulationChannel)
+		parameters[i] = e.ParameterControl.Sample(e.parameters[i], e.Rand)
+		rngs[i] = utils.DeriveRand(e.Rand)
 	}
-
-	for i := 0; i < e.PopulationSize; i++ {
-		pair := <-newPopulationChannel
-		newAgent, fitness := pair.Agent, pair.Fitness
+	// results are written back to their agent number, keeping the population order
+	results := make([]trialResult, e.PopulationSize)
+	e.forEachAgent(ctx, e.PopulationSize, func(worker, agentNumber int) {
+		results[agentNumber] = e.tryReplaceAgent(agentNumber, parameters[agentNumber], rngs[agentNumber], worker)
+	})
+	for i, result := range results {
+		if result.Agent == nil {
+			result.evaluatedAgent = evaluatedAgent{AgentFitnessPair{e.Population.GetRow(i), e.Fitness.Get(i)}, e.Violation.Get(i)}
+			result.parameters = e.parameters[i]
+		} else {
+			e.Evaluations++
+		}
+		newAgent, fitness := result.Agent, result.Fitness
 		newPopulation.SetRow(i, *newAgent)
-		if fitness <= e.CurrentBestFitness {
-			e.CurrentBestFitness = fitness
-			e.CurrentBestAgent = newAgent
+		newFitness.Set(i, fitness)
+		newViolation.Set(i, result.violation)
+		newParameters[i] = result.parameters
+		if result.success != nil {
+			successes = append(successes, *result.success)
 		}
+		e.offerBest(newAgent, fitness, result.violation)
 	}
 	e.Population = &newPopulation
-	fitnessImprovementRatio := (lastBestFitness-e.CurrentBestFitness)/lastBestFitness
-	if fitnessImprovementRatio <= e.StallFactor {
-		e.stallCount++
-	} else {
-		e.stallCount = 0
+	e.Fitness = newFitness
+	e.Violation = newViolation
+	e.parameters = newParameters
+	e.ParameterControl.Update(successes)
+	e.reducePopulation()
+	if e.RefinementInterval > 0 && (e.CurrentGeneration+1)%e.RefinementInterval == 0 {
+		e.refineElites(ctx)
 	}
+	e.updateStatistics()
+	e.BestFitnessHistory = append(e.BestFitnessHistory, e.CurrentBestFitness)
 	e.CurrentGeneration++
+	if e.ConstraintHandling != nil {
+		e.ConstraintHandling.Update(e)
+	}
+	e.notify(Observer.GenerationEnded)
+	e.notifyIfImproved(lastBestFitness, lastBestViolation)
 	return nil
 }
+
+// records why the evolution stopped and lets the observers know,
+// refining the best agent first unless the evolution was interrupted
+func (e *Evolver) stop(reason StopReason) {
+	if e.RefineFinalBest && reason != Cancelled && reason != DeadlineExceeded {
+		e.refineFinalBest()
+	}
+	e.StopReason = reason
+	e.notify(Observer.Terminated)
+}
@@ -0,0 +1,30 @@
+package differentialEvolution
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeededRunsAreReproducible(t *testing.T) {
+	first := NewEvolver(seededParams(3, 50))
+	expected := runToEnd(t, &first)
+	for _, workers := range []int{4, 1, 7} {
+		p := seededParams(3, 50)
+		p.Workers = workers
+		e := NewEvolver(p)
+		result := runToEnd(t, &e)
+		if !reflect.DeepEqual(result.BestAgent, expected.BestAgent) || result.BestFitness != expected.BestFitness ||
+			result.Evaluations != expected.Evaluations {
+			t.Errorf("run with %d workers ends with %v (%v), expected %v (%v)",
+				workers, result.BestAgent, result.BestFitness, expected.BestAgent, expected.BestFitness)
+		}
+		if !reflect.DeepEqual(e.Population, first.Population) {
+			t.Errorf("run with %d workers ends with a different population", workers)
+		}
+	}
+
+	other := NewEvolver(seededParams(4, 50))
+	if result := runToEnd(t, &other); reflect.DeepEqual(result.BestAgent, expected.BestAgent) {
+		t.Errorf("runs with different seeds end with the same best agent %v", result.BestAgent)
+	}
+}
@@ -0,0 +1,270 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"utils"
+)
+
+// Initializer generates the agents of the initial population
+type Initializer interface {
+	// Initialize returns at least `n` agents within `searchSpace`
+	// If it returns more, the evolver evaluates all of them and keeps the `n` fittest
+	Initialize(n int, searchSpace []utils.Range1D, rng *rand.Rand) *arrays.Array2D
+}
+
+// ValidateInitializer reports whether `initializer` cannot generate agents within `searchSpace`,
+// if it tells so through a `Validate(searchSpace []utils.Range1D) error` method
+func ValidateInitializer(initializer Initializer, searchSpace []utils.Range1D) error {
+	if v, ok := initializer.(interface {
+		Validate(searchSpace []utils.Range1D) error
+	}); ok {
+		return v.Validate(searchSpace)
+	}
+	return nil
+}
+
+// UniformInitializer samples every feature uniformly and independently
+type UniformInitializer struct{}
+
+func (UniformInitializer) Initialize(n int, searchSpace []utils.Range1D, rng *rand.Rand) *arrays.Array2D {
+	population := arrays.NewArray2D(n, len(searchSpace))
+	for i := 0; i < n; i++ {
+		for j, space := range searchSpace {
+			population.SetValue(i, j, utils.RandomInRange(rng, space))
+		}
+	}
+	return population
+}
+
+// LatinHypercube splits every feature's range into `n` equal strata and places
+// exactly one agent in each stratum of each feature
+type LatinHypercube struct{}
+
+func (LatinHypercube) Initialize(n int, searchSpace []utils.Range1D, rng *rand.Rand) *arrays.Array2D {
+	population := arrays.NewArray2D(n, len(searchSpace))
+	for j := range searchSpace {
+		strata := rng.Perm(n)
+		for i := 0; i < n; i++ {
+			unit := (float64(strata[i]) + rng.Float64()) / float64(n)
+			population.SetValue(i, j, searchSpace[j].FromUnit(unit))
+		}
+	}
+	return population
+}
+
+// Halton uses the Halton low-discrepancy sequence, one prime base per feature
+// The sequence is shifted by a random offset on each feature (Cranley-Patterson rotation),
+// so different seeds give different, equally well spread, populations
+type Halton struct{}
+
+func (Halton) Initialize(n int, searchSpace []utils.Range1D, rng *rand.Rand) *arrays.Array2D {
+	bases := primes(len(searchSpace))
+	shifts := randomShifts(len(searchSpace), rng)
+	population := arrays.NewArray2D(n, len(searchSpace))
+	for i := 0; i < n; i++ {
+		for j, space := range searchSpace {
+			// index 0 is the origin on every feature, so the sequence starts at 1
+			unit := math.Mod(radicalInverse(i+1, bases[j])+shifts[j], 1)
+			population.SetValue(i, j, space.FromUnit(unit))
+		}
+	}
+	return population
+}
+
+// Sobol uses the Sobol low-discrepancy sequence, with Joe and Kuo's direction numbers
+// for up to `SobolMaxFeatures` features, randomly shifted like `Halton`
+type Sobol struct{}
+
+// first feature plus one per entry of `sobolPolynomials`
+const SobolMaxFeatures = 21
+
+// degree, coefficients and initial direction numbers of the primitive polynomial of each
+// feature after the first (Joe and Kuo, new-joe-kuo-6.21201)
+var sobolPolynomials = []struct {
+	s, a int
+	m    []uint32
+}{
+	{1, 0, []uint32{1}},
+	{2, 1, []uint32{1, 3}},
+	{3, 1, []uint32{1, 3, 1}},
+	{3, 2, []uint32{1, 1, 1}},
+	{4, 1, []uint32{1, 1, 3, 3}},
+	{4, 4, []uint32{1, 3, 5, 13}},
+	{5, 2, []uint32{1, 1, 5, 5, 17}},
+	{5, 4, []uint32{1, 1, 5, 5, 5}},
+	{5, 7, []uint32{1, 1, 7, 11, 19}},
+	{5, 11, []uint32{1, 1, 5, 1, 1}},
+	{5, 13, []uint32{1, 1, 1, 3, 11}},
+	{5, 14, []uint32{1, 3, 5, 5, 31}},
+	{6, 1, []uint32{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint32{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint32{1, 3, 1, 13, 27, 49}},
+	{6, 19, []uint32{1, 1, 1, 15, 7, 5}},
+	{6, 22, []uint32{1, 3, 1, 15, 13, 25}},
+	{6, 25, []uint32{1, 1, 5, 5, 19, 61}},
+	{7, 1, []uint32{1, 3, 7, 11, 23, 15, 103}},
+	{7, 4, []uint32{1, 3, 7, 13, 13, 15, 69}},
+}
+
+const sobolBits = 32
+
+func (Sobol) Initialize(n int, searchSpace []utils.Range1D, rng *rand.Rand) *arrays.Array2D {
+	if len(searchSpace) > SobolMaxFeatures {
+		log.Fatalf("Sobol initialization supports at most %d features", SobolMaxFeatures)
+	}
+	directions := make([][]uint32, len(searchSpace))
+	for j := range directions {
+		directions[j] = sobolDirections(j)
+	}
+	shifts := randomShifts(len(searchSpace), rng)
+	population := arrays.NewArray2D(n, len(searchSpace))
+	x := make([]uint32, len(searchSpace))
+	for i := 0; i < n; i++ {
+		// Gray code order: point i+1 flips the direction number of the lowest zero bit of i,
+		// skipping the origin
+		c := 0
+		for value := i; value&1 == 1; value >>= 1 {
+			c++
+		}
+		for j, space := range searchSpace {
+			x[j] ^= directions[j][c]
+			unit := math.Mod(float64(x[j])/math.Exp2(sobolBits)+shifts[j], 1)
+			population.SetValue(i, j, space.FromUnit(unit))
+		}
+	}
+	return population
+}
+
+func sobolDirections(feature int) []uint32 {
+	v := make([]uint32, sobolBits)
+	if feature == 0 {
+		for k := range v {
+			v[k] = 1 << uint(sobolBits-1-k)
+		}
+		return v
+	}
+	p := sobolPolynomials[feature-1]
+	for k := 0; k < sobolBits; k++ {
+		if k < p.s {
+			v[k] = p.m[k] << uint(sobolBits-1-k)
+			continue
+		}
+		v[k] = v[k-p.s] ^ (v[k-p.s] >> uint(p.s))
+		for l := 1; l < p.s; l++ {
+			if (p.a>>uint(p.s-1-l))&1 == 1 {
+				v[k] ^= v[k-l]
+			}
+		}
+	}
+	return v
+}
+
+// OppositionInitializer generates `n` agents with `Base` (uniform if nil) along with their
+// opposite points, letting the evolver keep the fittest half (Rahnamayan et al., 2008)
+type OppositionInitializer struct {
+	Base Initializer
+}
+
+func (o OppositionInitializer) Initialize(n int, searchSpace []utils.Range1D, rng *rand.Rand) *arrays.Array2D {
+	base := o.Base
+	if base == nil {
+		base = UniformInitializer{}
+	}
+	population := base.Initialize(n, searchSpace, rng)
+	for i := 0; i < n; i++ {
+		opposite := population.GetRow(i).Copy()
+		for j, space := range searchSpace {
+			opposite.Set(j, utils.ConstrainValue(space.LowerBound+space.UpperBound-opposite.Get(j), space))
+		}
+		population.Append(opposite)
+	}
+	return population
+}
+
+// SeededInitializer starts the population with caller-supplied `Agents`, such as the
+// current joint configuration of a robot, and fills the rest with `Base` (uniform if nil)
+// Seeds are brought within the search space following each range's policy
+type SeededInitializer struct {
+	Agents []*arrays.Array1D
+	Base   Initializer
+}
+
+// Validate reports seeds whose size differs from that of the search space
+func (s SeededInitializer) Validate(searchSpace []utils.Range1D) error {
+	for i, seed := range s.Agents {
+		if seed.Length() != len(searchSpace) {
+			return fmt.Errorf("seed %d of size %d, expected %d", i, seed.Length(), len(searchSpace))
+		}
+	}
+	return ValidateInitializer(s.Base, searchSpace)
+}
+
+func (s SeededInitializer) Initialize(n int, searchSpace []utils.Range1D, rng *rand.Rand) *arrays.Array2D {
+	if err := s.Validate(searchSpace); err != nil {
+		log.Fatalf("Invalid seeded initialization: %v", err)
+	}
+	base := s.Base
+	if base == nil {
+		base = UniformInitializer{}
+	}
+	population := &arrays.Array2D{}
+	for _, seed := range s.Agents {
+		if population.NRows() == n {
+			break
+		}
+		agent := seed.Copy()
+		for j, space := range searchSpace {
+			agent.Set(j, utils.HandleBounds(rng, agent.Get(j), utils.ConstrainValue(agent.Get(j), space), space))
+		}
+		population.Append(agent)
+	}
+	if remaining := n - population.NRows(); remaining > 0 {
+		for _, agent := range *base.Initialize(remaining, searchSpace, rng) {
+			population.Append(agent)
+		}
+	}
+	return population
+}
+
+func randomShifts(n int, rng *rand.Rand) []float64 {
+	shifts := make([]float64, n)
+	for i := range shifts {
+		shifts[i] = rng.Float64()
+	}
+	return shifts
+}
+
+// van der Corput radical inverse of `i` in `base`
+func radicalInverse(i, base int) float64 {
+	result, fraction := 0.0, 1.0/float64(base)
+	for ; i > 0; i /= base {
+		result += float64(i%base) * fraction
+		fraction /= float64(base)
+	}
+	return result
+}
+
+// first `n` prime numbers
+func primes(n int) []int {
+	var found []int
+	for candidate := 2; len(found) < n; candidate++ {
+		isPrime := true
+		for _, p := range found {
+			if p*p > candidate {
+				break
+			}
+			if candidate%p == 0 {
+				isPrime = false
+				break
+			}
+		}
+		if isPrime {
+			found = append(found, candidate)
+		}
+	}
+	return found
+}
@@ -0,0 +1,271 @@
+package differentialEvolution
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+	"utils"
+)
+
+// MigrationTopology tells which islands receive the migrants of each island
+type MigrationTopology interface {
+	// Destinations returns the numbers of the islands `island` sends its migrants to
+	Destinations(island, islands int) []int
+}
+
+// RingTopology sends migrants to the next island, the last one sending to the first
+type RingTopology struct{}
+
+func (RingTopology) Destinations(island, islands int) []int {
+	if islands < 2 {
+		return nil
+	}
+	return []int{(island + 1) % islands}
+}
+
+// FullyConnectedTopology sends migrants to every other island
+type FullyConnectedTopology struct{}
+
+func (FullyConnectedTopology) Destinations(island, islands int) []int {
+	var destinations []int
+	for i := 0; i < islands; i++ {
+		if i != island {
+			destinations = append(destinations, i)
+		}
+	}
+	return destinations
+}
+
+// ReplacementPolicy chooses the agents of an island that immigrants take the place of
+// An immigrant only replaces an agent it is preferred to, so islands never lose their best agent
+type ReplacementPolicy int
+
+const (
+	ReplaceWorst ReplacementPolicy = iota
+	ReplaceRandom
+)
+
+// Archipelago evolves several populations (islands) concurrently, one goroutine each,
+// sending copies of the best agents of each island to others every `MigrationInterval` generations
+// Islands may use different strategies and parameters, but must share the same fitness function,
+// constraints and constraint handling
+type Archipelago struct {
+	Islands           []*Evolver
+	Topology          MigrationTopology
+	MigrationInterval int
+	Migrants          int
+	Replacement       ReplacementPolicy
+	// number of migrations so far
+	Migrations int
+	StopReason StopReason
+	// replacements at random are drawn from `Rand`, island sources are derived from it
+	Rand *rand.Rand
+}
+
+type NewArchipelagoParams struct {
+	// islands without `Rand` get a source derived from the archipelago's, and run on a single
+	// worker unless `Workers` is set, as the islands already run in parallel
+	Islands           []NewEvolverParams
+	Topology          MigrationTopology // defaults to `RingTopology`
+	MigrationInterval int               // in generations
+	Migrants          int               // defaults to 1
+	Replacement       ReplacementPolicy
+	Rand              *rand.Rand // defaults to a source seeded with the current time
+}
+
+func NewArchipelago(p NewArchipelagoParams) Archipelago {
+	if len(p.Islands) == 0 {
+		log.Fatalf("Archipelago needs at least one island")
+	}
+	if p.MigrationInterval <= 0 {
+		log.Fatalf("Migration interval must be greater than 0")
+	}
+	if p.Topology == nil {
+		p.Topology = RingTopology{}
+	}
+	if p.Migrants <= 0 {
+		p.Migrants = 1
+	}
+	if p.Rand == nil {
+		p.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	islands := make([]*Evolver, len(p.Islands))
+	for i, params := range p.Islands {
+		minPopulationSize := params.PopulationSize
+		if params.MinPopulationSize > 0 {
+			minPopulationSize = params.MinPopulationSize
+		}
+		if p.Migrants > minPopulationSize {
+			log.Fatalf("Island %d may have fewer agents than migrants", i)
+		}
+		if params.Rand == nil {
+			params.Rand = utils.DeriveRand(p.Rand)
+		}
+		if params.Workers <= 0 {
+			params.Workers = 1
+		}
+		island := NewEvolver(params)
+		islands[i] = &island
+	}
+	return Archipelago{
+		Islands:           islands,
+		Topology:          p.Topology,
+		MigrationInterval: p.MigrationInterval,
+		Migrants:          p.Migrants,
+		Replacement:       p.Replacement,
+		Rand:              p.Rand,
+	}
+}
+
+// Run evolves the islands until every one of them meets its termination criterion, one of them
+// reaches its target fitness, or `ctx` ends, initializing their populations first if needed
+// The result holds the best agent of all islands, with the evaluations of all islands added up
+func (a *Archipelago) Run(ctx context.Context) (Result, error) {
+	a.forEachIsland(func(island *Evolver) error {
+		if island.Population == nil {
+			island.initializePopulation(ctx)
+		}
+		return nil
+	})
+	for {
+		err := a.forEachIsland(func(island *Evolver) error {
+			for i := 0; i < a.MigrationInterval; i++ {
+				if reason := contextStopReason(ctx); reason != NotStopped {
+					island.stop(reason)
+					return nil
+				}
+				if !island.shouldContinue(ctx) {
+					return nil
+				}
+				if err := island.evolve(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return a.result(), err
+		}
+		if a.StopReason = a.stopReason(ctx); a.StopReason != NotStopped {
+			break
+		}
+		a.migrate()
+	}
+	return a.result(), nil
+}
+
+// runs `task` on every island concurrently, returning the first error
+func (a *Archipelago) forEachIsland(task func(island *Evolver) error) error {
+	errs := make([]error, len(a.Islands))
+	var wg sync.WaitGroup
+	for i, island := range a.Islands {
+		if island.StopReason != NotStopped {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, island *Evolver) {
+			defer wg.Done()
+			errs[i] = task(island)
+		}(i, island)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Archipelago) stopReason(ctx context.Context) StopReason {
+	if reason := contextStopReason(ctx); reason != NotStopped {
+		return reason
+	}
+	for _, island := range a.Islands {
+		if island.StopReason == TargetFitnessReached {
+			return TargetFitnessReached
+		}
+	}
+	for _, island := range a.Islands {
+		if island.StopReason == NotStopped {
+			return NotStopped
+		}
+	}
+	return a.bestIsland().StopReason
+}
+
+// sends copies of the best agents of each island to its destinations
+// All emigrants are picked before any island receives immigrants, so the order islands
+// are visited in does not matter
+func (a *Archipelago) migrate() {
+	emigrants := make([][]evaluatedAgent, len(a.Islands))
+	for i, island := range a.Islands {
+		island.rankPopulation()
+		for _, n := range island.ranking[:a.Migrants] {
+			emigrants[i] = append(emigrants[i], evaluatedAgent{
+				AgentFitnessPair{island.Population.GetRow(n).Copy(), island.Fitness.Get(n)},
+				island.Violation.Get(n),
+			})
+		}
+	}
+	for i := range a.Islands {
+		for _, destination := range a.Topology.Destinations(i, len(a.Islands)) {
+			a.Islands[destination].receive(emigrants[i], a.Replacement, a.Rand)
+		}
+	}
+	a.Migrations++
+}
+
+func (e *Evolver) receive(immigrants []evaluatedAgent, replacement ReplacementPolicy, rng *rand.Rand) {
+	e.rankPopulation()
+	var candidates []int
+	switch replacement {
+	case ReplaceWorst:
+		candidates = e.ranking[e.PopulationSize-len(immigrants):]
+	case ReplaceRandom:
+		candidates = *utils.PickRandom(rng, e.PopulationSize, len(immigrants), -1)
+	}
+	lastBestFitness, lastBestViolation := e.CurrentBestFitness, e.CurrentBestViolation
+	for i, immigrant := range immigrants {
+		n := candidates[i]
+		if !e.better(immigrant.Fitness, immigrant.violation, e.Fitness.Get(n), e.Violation.Get(n)) {
+			continue
+		}
+		(*e.Population)[n] = immigrant.Agent.Copy()
+		e.Fitness.Set(n, immigrant.Fitness)
+		e.Violation.Set(n, immigrant.violation)
+		e.parameters[n] = e.initialParameters()
+		e.offerBest(immigrant.Agent, immigrant.Fitness, immigrant.violation)
+	}
+	e.updateStatistics()
+	e.notifyIfImproved(lastBestFitness, lastBestViolation)
+}
+
+func (a *Archipelago) bestIsland() *Evolver {
+	best := a.Islands[0]
+	for _, island := range a.Islands[1:] {
+		if island.better(island.CurrentBestFitness, island.CurrentBestViolation, best.CurrentBestFitness, best.CurrentBestViolation) {
+			best = island
+		}
+	}
+	return best
+}
+
+func (a *Archipelago) result() Result {
+	best := a.bestIsland()
+	r := Result{
+		BestAgent:   best.CurrentBestAgent,
+		BestFitness: best.CurrentBestFitness,
+		StopReason:  a.StopReason,
+	}
+	for _, island := range a.Islands {
+		if island.CurrentGeneration > r.Generations {
+			r.Generations = island.CurrentGeneration
+		}
+		r.Evaluations += island.Evaluations
+		r.Restarts = append(r.Restarts, island.Restarts...)
+	}
+	return r
+}
@@ -0,0 +1,252 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"context"
+	"math"
+	"sort"
+	"utils"
+)
+
+// LocalSearch refines an agent by a deterministic, gradient-free search around it
+// Every point it evaluates lies within `searchSpace`
+type LocalSearch interface {
+	// Refine returns the fittest agent found starting from `agent`, which has `fitness`,
+	// along with its fitness and the number of evaluations used
+	Refine(agent *arrays.Array1D, fitness float64, f FitnessFunction, searchSpace []utils.Range1D) (*arrays.Array1D, float64, int)
+}
+
+// RefinementStats adds up the refinements made during the evolution
+type RefinementStats struct {
+	Runs        int
+	Evaluations int
+	// decrease of the best fitness due to refinements
+	Improvement float64
+}
+
+// NelderMead is the downhill simplex method, with every trial point clamped to the search space
+type NelderMead struct {
+	// size of the initial simplex, as a fraction of each feature's range, defaults to 0.05
+	Step float64
+	// defaults to 100 times the agent size
+	MaxEvaluations int
+	// stops once the fitness of the simplex vertices spreads less than `Tolerance`
+	Tolerance float64
+}
+
+func (nm NelderMead) Refine(agent *arrays.Array1D, fitness float64, f FitnessFunction, searchSpace []utils.Range1D) (*arrays.Array1D, float64, int) {
+	n := agent.Length()
+	step, maxEvaluations := nm.Step, nm.MaxEvaluations
+	if step <= 0 {
+		step = 0.05
+	}
+	if maxEvaluations <= 0 {
+		maxEvaluations = 100 * n
+	}
+	evaluations := 0
+	evaluate := func(point *arrays.Array1D) float64 {
+		evaluations++
+		return f(point)
+	}
+	vertices := []AgentFitnessPair{{agent.Copy(), fitness}}
+	for j, space := range searchSpace {
+		vertex := agent.Copy()
+		delta := step * (space.UpperBound - space.LowerBound)
+		if vertex.Get(j)+delta > space.UpperBound {
+			delta = -delta
+		}
+		vertex.Set(j, utils.ConstrainValue(vertex.Get(j)+delta, space))
+		vertices = append(vertices, AgentFitnessPair{vertex, evaluate(vertex)})
+	}
+	// x = c + coefficient*(c - worst), clamped
+	along := func(centroid, worst *arrays.Array1D, coefficient float64) *arrays.Array1D {
+		point := centroid.Add(centroid.Subtract(worst).MultiplyByConstant(coefficient))
+		for j, space := range searchSpace {
+			point.Set(j, utils.ConstrainValue(point.Get(j), space))
+		}
+		return point
+	}
+	for evaluations < maxEvaluations {
+		sort.SliceStable(vertices, func(a, b int) bool { return vertices[a].Fitness < vertices[b].Fitness })
+		best, worst := vertices[0], vertices[n]
+		if worst.Fitness-best.Fitness <= nm.Tolerance {
+			break
+		}
+		centroid := make(arrays.Array1D, n)
+		for _, v := range vertices[:n] {
+			centroid = *centroid.Add(v.Agent)
+		}
+		centroid = *centroid.MultiplyByConstant(1 / float64(n))
+
+		reflected := along(&centroid, worst.Agent, 1)
+		reflectedFitness := evaluate(reflected)
+		switch {
+		case reflectedFitness < best.Fitness:
+			expanded := along(&centroid, worst.Agent, 2)
+			if expandedFitness := evaluate(expanded); expandedFitness < reflectedFitness {
+				vertices[n] = AgentFitnessPair{expanded, expandedFitness}
+			} else {
+				vertices[n] = AgentFitnessPair{reflected, reflectedFitness}
+			}
+		case reflectedFitness < vertices[n-1].Fitness:
+			vertices[n] = AgentFitnessPair{reflected, reflectedFitness}
+		default:
+			contracted := along(&centroid, worst.Agent, -0.5)
+			if contractedFitness := evaluate(contracted); contractedFitness < worst.Fitness {
+				vertices[n] = AgentFitnessPair{contracted, contractedFitness}
+				continue
+			}
+			// shrink every vertex towards the best one
+			for i := 1; i <= n; i++ {
+				shrunk := best.Agent.Add(vertices[i].Agent.Subtract(best.Agent).MultiplyByConstant(0.5))
+				for j, space := range searchSpace {
+					shrunk.Set(j, utils.ConstrainValue(shrunk.Get(j), space))
+				}
+				vertices[i] = AgentFitnessPair{shrunk, evaluate(shrunk)}
+			}
+		}
+	}
+	best := vertices[0]
+	for _, v := range vertices[1:] {
+		if v.Fitness < best.Fitness {
+			best = v
+		}
+	}
+	return best.Agent, best.Fitness, evaluations
+}
+
+// HookeJeeves is pattern search: exploratory moves along each feature, followed by moves
+// along the direction that improved, halving the steps when no move improves
+// Every trial point is clamped to the search space
+type HookeJeeves struct {
+	// initial step, as a fraction of each feature's range, defaults to 0.05
+	Step float64
+	// stops once the step is smaller than `MinStep` (fraction of the range), defaults to 1e-6
+	MinStep float64
+	// defaults to 100 times the agent size
+	MaxEvaluations int
+}
+
+func (hj HookeJeeves) Refine(agent *arrays.Array1D, fitness float64, f FitnessFunction, searchSpace []utils.Range1D) (*arrays.Array1D, float64, int) {
+	n := agent.Length()
+	step, minStep, maxEvaluations := hj.Step, hj.MinStep, hj.MaxEvaluations
+	if step <= 0 {
+		step = 0.05
+	}
+	if minStep <= 0 {
+		minStep = 1e-6
+	}
+	if maxEvaluations <= 0 {
+		maxEvaluations = 100 * n
+	}
+	evaluations := 0
+	// tries moving each feature of `base` up or down by the current step
+	explore := func(base *arrays.Array1D, baseFitness float64) (*arrays.Array1D, float64) {
+		point := base.Copy()
+		for j, space := range searchSpace {
+			delta := step * (space.UpperBound - space.LowerBound)
+			for _, candidate := range []float64{point.Get(j) + delta, point.Get(j) - delta} {
+				if evaluations >= maxEvaluations {
+					return point, baseFitness
+				}
+				candidate = utils.ConstrainValue(candidate, space)
+				if candidate == point.Get(j) {
+					continue
+				}
+				trial := point.Copy()
+				trial.Set(j, candidate)
+				evaluations++
+				if trialFitness := f(trial); trialFitness < baseFitness {
+					point, baseFitness = trial, trialFitness
+					break
+				}
+			}
+		}
+		return point, baseFitness
+	}
+	best, bestFitness := agent.Copy(), fitness
+	for step >= minStep && evaluations < maxEvaluations {
+		point, pointFitness := explore(best, bestFitness)
+		if pointFitness >= bestFitness {
+			step /= 2
+			continue
+		}
+		// keep moving along the improving direction while it pays off
+		for pointFitness < bestFitness && evaluations < maxEvaluations {
+			pattern := point.Add(point.Subtract(best))
+			for j, space := range searchSpace {
+				pattern.Set(j, utils.ConstrainValue(pattern.Get(j), space))
+			}
+			best, bestFitness = point, pointFitness
+			evaluations++
+			patternFitness := f(pattern)
+			point, pointFitness = explore(pattern, patternFitness)
+		}
+	}
+	return best, bestFitness, evaluations
+}
+
+// refines the `RefineElites` best agents in parallel, each with the fitness function of its worker
+func (e *Evolver) refineElites(ctx context.Context) {
+	e.rankPopulation()
+	elites := e.ranking[:int(math.Min(float64(e.RefineElites), float64(e.PopulationSize)))]
+	results := make([]evaluatedAgent, len(elites))
+	evaluations := make([]int, len(elites))
+	e.forEachAgent(ctx, len(elites), func(worker, i int) {
+		n := elites[i]
+		results[i], evaluations[i] = e.refine(worker, e.Population.GetRow(n), e.Fitness.Get(n), e.Violation.Get(n))
+	})
+	lastBestFitness := e.CurrentBestFitness
+	for i, n := range elites {
+		if results[i].Agent == nil {
+			continue
+		}
+		e.Refinement.Runs++
+		e.Refinement.Evaluations += evaluations[i]
+		e.Evaluations += evaluations[i]
+		if e.better(results[i].Fitness, results[i].violation, e.Fitness.Get(n), e.Violation.Get(n)) {
+			(*e.Population)[n] = results[i].Agent
+			e.Fitness.Set(n, results[i].Fitness)
+			e.Violation.Set(n, results[i].violation)
+		}
+		e.offerBest(results[i].Agent, results[i].Fitness, results[i].violation)
+	}
+	e.Refinement.Improvement += lastBestFitness - e.CurrentBestFitness
+}
+
+// refines the best agent found when the evolution ends
+func (e *Evolver) refineFinalBest() {
+	result, used := e.refine(0, e.CurrentBestAgent, e.CurrentBestFitness, e.CurrentBestViolation)
+	e.Refinement.Runs++
+	e.Refinement.Evaluations += used
+	e.Evaluations += used
+	if e.better(result.Fitness, result.violation, e.CurrentBestFitness, e.CurrentBestViolation) {
+		e.Refinement.Improvement += e.CurrentBestFitness - result.Fitness
+		e.CurrentBestFitness = result.Fitness
+		e.CurrentBestViolation = result.violation
+		e.CurrentBestAgent = result.Agent.Copy()
+		e.notify(Observer.NewBest)
+	}
+}
+
+// runs the local search from `agent` with the functions of `worker`
+// Local searches only see the fitness, so with constraints, points that violate them are
+// given an infinite fitness and a feasible agent stays feasible
+func (e *Evolver) refine(worker int, agent *arrays.Array1D, fitness, violation float64) (evaluatedAgent, int) {
+	f := e.fitnessFunctions[worker]
+	if len(e.constraintFunctions[worker]) > 0 {
+		f = func(agent *arrays.Array1D) float64 {
+			fitness, violation := e.evaluate(worker, agent)
+			if violation > 0 {
+				return math.Inf(1)
+			}
+			return fitness
+		}
+	}
+	refined, refinedFitness, used := e.LocalSearch.Refine(agent, fitness, f, e.SearchSpace)
+	if math.IsInf(refinedFitness, 1) {
+		return evaluatedAgent{AgentFitnessPair{agent, fitness}, violation}, used
+	}
+	// only the constraints are evaluated again, the fitness is that given by the local search
+	return evaluatedAgent{AgentFitnessPair{refined, refinedFitness}, e.violation(worker, refined)}, used
+}
@@ -0,0 +1,434 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"time"
+	"utils"
+)
+
+// VectorFitnessFunction returns the fitness of `agent` on each objective, all of them minimized
+type VectorFitnessFunction func(agent *arrays.Array1D) arrays.Array1D
+
+// VectorFitnessFunctionFactory builds a vector fitness function for a single worker,
+// like `FitnessFunctionFactory`
+type VectorFitnessFunctionFactory func() VectorFitnessFunction
+
+// ParetoSolution is an agent along with its fitness on each objective
+type ParetoSolution struct {
+	Agent   *arrays.Array1D
+	Fitness arrays.Array1D
+}
+
+// MultiObjectiveEvolver minimizes several objectives at once, following GDE3 (Kukkonen and Lampinen, 2005):
+// trial vectors generated by DE/rand/1/bin replace the agents they dominate, are dropped if dominated,
+// and are otherwise added to the population, which is then cut back to its size by non-dominated
+// sorting and crowding distance, as in NSGA-II
+// The non-dominated agents found so far are kept in `Archive`, pruned by crowding distance
+// down to `ArchiveSize`
+type MultiObjectiveEvolver struct {
+	AgentSize       int
+	PopulationSize  int
+	Objectives      int
+	CrossoverRate   float64
+	WeightingFactor float64
+	SearchSpace     []utils.Range1D
+	ArchiveSize     int
+	// the evolution stops on any of these that is greater than 0
+	MaxGenerations int
+	MaxEvaluations int
+	StopReason     StopReason
+
+	CurrentGeneration int
+	Evaluations       int
+	Population        *arrays.Array2D
+	Fitness           []arrays.Array1D
+	Archive           []ParetoSolution
+
+	FitnessFunction        VectorFitnessFunction
+	FitnessFunctionFactory VectorFitnessFunctionFactory
+	Workers                int
+	fitnessFunctions       []VectorFitnessFunction
+	Rand                   *rand.Rand
+}
+
+type NewMultiObjectiveEvolverParams struct {
+	AgentSize       int
+	PopulationSize  int
+	Objectives      int
+	CrossoverRate   float64
+	WeightingFactor float64
+	SearchSpace     []utils.Range1D
+	ArchiveSize     int // defaults to `PopulationSize`
+	MaxGenerations  int
+	MaxEvaluations  int
+	// if `FitnessFunctionFactory` is nil, `FitnessFunction` is shared by all workers
+	// and must be safe for concurrent use
+	FitnessFunction        VectorFitnessFunction
+	FitnessFunctionFactory VectorFitnessFunctionFactory
+	Workers                int        // defaults to the number of CPUs
+	Rand                   *rand.Rand // defaults to a source seeded with the current time
+}
+
+// MultiObjectiveResult is the outcome of `MultiObjectiveEvolver.Run`
+type MultiObjectiveResult struct {
+	ParetoFront []ParetoSolution
+	Generations int
+	Evaluations int
+	StopReason  StopReason
+}
+
+func NewMultiObjectiveEvolver(p NewMultiObjectiveEvolverParams) MultiObjectiveEvolver {
+	if p.AgentSize != len(p.SearchSpace) {
+		if len(p.SearchSpace) != 1 {
+			log.Fatalf("Invalid search space")
+		}
+		for i := 0; i < p.AgentSize-1; i++ {
+			p.SearchSpace = append(p.SearchSpace, p.SearchSpace[0])
+		}
+	}
+	for j, space := range p.SearchSpace {
+		if err := space.Validate(); err != nil {
+			log.Fatalf("Invalid search space for feature %d: %v", j, err)
+		}
+	}
+	if p.Objectives < 2 {
+		log.Fatalf("Multi-objective evolution requires at least 2 objectives")
+	}
+	if p.PopulationSize <= 3 {
+		log.Fatalf("Population size must be greater than 3")
+	}
+	if p.MaxGenerations <= 0 && p.MaxEvaluations <= 0 {
+		log.Fatalf("Max generations or max evaluations must be set")
+	}
+	if p.ArchiveSize <= 0 {
+		p.ArchiveSize = p.PopulationSize
+	}
+	if p.Workers <= 0 {
+		p.Workers = runtime.NumCPU()
+	}
+	if p.Rand == nil {
+		p.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	e := MultiObjectiveEvolver{
+		AgentSize:              p.AgentSize,
+		PopulationSize:         p.PopulationSize,
+		Objectives:             p.Objectives,
+		CrossoverRate:          p.CrossoverRate,
+		WeightingFactor:        p.WeightingFactor,
+		SearchSpace:            p.SearchSpace,
+		ArchiveSize:            p.ArchiveSize,
+		MaxGenerations:         p.MaxGenerations,
+		MaxEvaluations:         p.MaxEvaluations,
+		FitnessFunction:        p.FitnessFunction,
+		FitnessFunctionFactory: p.FitnessFunctionFactory,
+		Workers:                p.Workers,
+		Rand:                   p.Rand,
+	}
+	e.fitnessFunctions = make([]VectorFitnessFunction, e.Workers)
+	for i := range e.fitnessFunctions {
+		if e.FitnessFunctionFactory != nil {
+			e.fitnessFunctions[i] = e.FitnessFunctionFactory()
+		} else {
+			e.fitnessFunctions[i] = e.FitnessFunction
+		}
+	}
+	return e
+}
+
+func (e *MultiObjectiveEvolver) InitializePopulation() {
+	e.Population = UniformInitializer{}.Initialize(e.PopulationSize, e.SearchSpace, e.Rand)
+	e.Fitness = e.evaluate(context.Background(), *e.Population)
+	e.Archive = nil
+	e.updateArchive()
+	e.StopReason = NotStopped
+}
+
+// evaluates `agents` on the worker pool; agents not reached if `ctx` ends are left with nil fitness
+func (e *MultiObjectiveEvolver) evaluate(ctx context.Context, agents arrays.Array2D) []arrays.Array1D {
+	fitness := make([]arrays.Array1D, len(agents))
+	forEachAgent(ctx, e.Workers, len(agents), func(worker, i int) {
+		fitness[i] = e.fitnessFunctions[worker](agents[i])
+	})
+	for _, f := range fitness {
+		if f != nil {
+			if f.Length() != e.Objectives {
+				log.Fatalf("Fitness function returned %d objectives, expected %d", f.Length(), e.Objectives)
+			}
+			e.Evaluations++
+		}
+	}
+	return fitness
+}
+
+// ShouldContinue checks the generation and evaluation limits, recording in `StopReason` why the evolution stopped
+func (e *MultiObjectiveEvolver) ShouldContinue() bool {
+	switch {
+	case e.MaxGenerations > 0 && e.CurrentGeneration >= e.MaxGenerations:
+		e.StopReason = MaxGenerationsReached
+	case e.MaxEvaluations > 0 && e.Evaluations >= e.MaxEvaluations:
+		e.StopReason = MaxEvaluationsReached
+	default:
+		return true
+	}
+	return false
+}
+
+func (e *MultiObjectiveEvolver) Evolve() error {
+	return e.evolve(context.Background())
+}
+
+func (e *MultiObjectiveEvolver) evolve(ctx context.Context) error {
+	if e.Population == nil {
+		return fmt.Errorf("population not initialized")
+	}
+	trials := make(arrays.Array2D, e.PopulationSize)
+	for i := range trials {
+		trials[i] = e.trial(i, utils.DeriveRand(e.Rand))
+	}
+	trialFitness := e.evaluate(ctx, trials)
+
+	population := make(arrays.Array2D, 0, 2*e.PopulationSize)
+	var fitness []arrays.Array1D
+	for i, trial := range trials {
+		parent, parentFitness := e.Population.GetRow(i), e.Fitness[i]
+		switch {
+		case trialFitness[i] == nil || dominates(parentFitness, trialFitness[i]):
+			population = append(population, parent)
+			fitness = append(fitness, parentFitness)
+		case weaklyDominates(trialFitness[i], parentFitness):
+			population = append(population, trial)
+			fitness = append(fitness, trialFitness[i])
+		default:
+			// neither is better, both are kept until the population is cut back
+			population = append(population, parent, trial)
+			fitness = append(fitness, parentFitness, trialFitness[i])
+		}
+	}
+	keep := selectByRankAndCrowding(fitness, e.PopulationSize)
+	e.Population = &arrays.Array2D{}
+	e.Fitness = make([]arrays.Array1D, len(keep))
+	for i, n := range keep {
+		e.Population.Append(population[n])
+		e.Fitness[i] = fitness[n]
+	}
+	e.updateArchive()
+	e.CurrentGeneration++
+	return nil
+}
+
+// DE/rand/1/bin trial vector for agent `i`
+func (e *MultiObjectiveEvolver) trial(i int, rng *rand.Rand) *arrays.Array1D {
+	r := *utils.PickRandom(rng, e.PopulationSize, 3, i)
+	x := e.Population.GetRow(i)
+	base, a, b := e.Population.GetRow(r[0]), e.Population.GetRow(r[1]), e.Population.GetRow(r[2])
+	mutated := base.Add(b.Subtract(a).MultiplyByConstant(e.WeightingFactor))
+	trial := x.Copy()
+	randomIndex := rng.Intn(e.AgentSize)
+	for j := range *trial {
+		if rng.Float64() <= e.CrossoverRate || j == randomIndex {
+			trial.Set(j, mutatedGene(rng, mutated.Get(j), x.Get(j), e.SearchSpace[j]))
+		}
+	}
+	return trial
+}
+
+// adds the non-dominated agents of the population to the archive, dropping the archived
+// solutions they dominate, then prunes the archive by crowding distance
+func (e *MultiObjectiveEvolver) updateArchive() {
+	candidates := append([]ParetoSolution(nil), e.Archive...)
+	for i, agent := range *e.Population {
+		candidates = append(candidates, ParetoSolution{agent.Copy(), *e.Fitness[i].Copy()})
+	}
+	fitness := make([]arrays.Array1D, len(candidates))
+	for i, c := range candidates {
+		fitness[i] = c.Fitness
+	}
+	front := nonDominatedSort(fitness)[0]
+	// identical solutions do not dominate each other, keep only one of each
+	var archive []ParetoSolution
+	var archiveFitness []arrays.Array1D
+	for _, n := range front {
+		duplicate := false
+		for _, f := range archiveFitness {
+			if equalFitness(f, fitness[n]) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			archive = append(archive, candidates[n])
+			archiveFitness = append(archiveFitness, fitness[n])
+		}
+	}
+	// prune one solution at a time, recomputing distances, which keeps the front better spread
+	for len(archive) > e.ArchiveSize {
+		distances := crowdingDistances(archiveFitness, allIndices(len(archive)))
+		crowded := 0
+		for i := range distances {
+			if distances[i] < distances[crowded] {
+				crowded = i
+			}
+		}
+		archive = append(archive[:crowded], archive[crowded+1:]...)
+		archiveFitness = append(archiveFitness[:crowded], archiveFitness[crowded+1:]...)
+	}
+	e.Archive = archive
+}
+
+// ParetoFront returns a copy of the archive, sorted by the first objective
+func (e *MultiObjectiveEvolver) ParetoFront() []ParetoSolution {
+	front := make([]ParetoSolution, len(e.Archive))
+	for i, s := range e.Archive {
+		front[i] = ParetoSolution{s.Agent.Copy(), *s.Fitness.Copy()}
+	}
+	sort.SliceStable(front, func(a, b int) bool { return front[a].Fitness.Get(0) < front[b].Fitness.Get(0) })
+	return front
+}
+
+// Run evolves the population until a limit is reached or `ctx` ends, initializing it first if needed
+func (e *MultiObjectiveEvolver) Run(ctx context.Context) (MultiObjectiveResult, error) {
+	if e.Population == nil {
+		e.InitializePopulation()
+	}
+	for {
+		if reason := contextStopReason(ctx); reason != NotStopped {
+			e.StopReason = reason
+			break
+		}
+		if !e.ShouldContinue() {
+			break
+		}
+		if err := e.evolve(ctx); err != nil {
+			return e.result(), err
+		}
+	}
+	return e.result(), nil
+}
+
+func (e *MultiObjectiveEvolver) result() MultiObjectiveResult {
+	return MultiObjectiveResult{
+		ParetoFront: e.ParetoFront(),
+		Generations: e.CurrentGeneration,
+		Evaluations: e.Evaluations,
+		StopReason:  e.StopReason,
+	}
+}
+
+// whether `a` is no worse than `b` on every objective
+func weaklyDominates(a, b arrays.Array1D) bool {
+	for i := range a {
+		if a[i] > b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// whether `a` is no worse than `b` on every objective, and better on at least one
+func dominates(a, b arrays.Array1D) bool {
+	return weaklyDominates(a, b) && !equalFitness(a, b)
+}
+
+func equalFitness(a, b arrays.Array1D) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splits indices of `fitness` into fronts: the first holds the non-dominated ones, the second
+// those only dominated by the first, and so on (Deb et al., 2002)
+func nonDominatedSort(fitness []arrays.Array1D) [][]int {
+	dominatedBy := make([]int, len(fitness))
+	dominating := make([][]int, len(fitness))
+	var fronts [][]int
+	var current []int
+	for i := range fitness {
+		for j := range fitness {
+			if dominates(fitness[i], fitness[j]) {
+				dominating[i] = append(dominating[i], j)
+			} else if dominates(fitness[j], fitness[i]) {
+				dominatedBy[i]++
+			}
+		}
+		if dominatedBy[i] == 0 {
+			current = append(current, i)
+		}
+	}
+	for len(current) > 0 {
+		fronts = append(fronts, current)
+		var next []int
+		for _, i := range current {
+			for _, j := range dominating[i] {
+				dominatedBy[j]--
+				if dominatedBy[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		current = next
+	}
+	return fronts
+}
+
+// crowding distance of each of the `members` of a front, in the same order
+// Solutions at the ends of any objective get an infinite distance
+func crowdingDistances(fitness []arrays.Array1D, members []int) []float64 {
+	distances := make([]float64, len(members))
+	if len(members) == 0 {
+		return distances
+	}
+	order := allIndices(len(members))
+	for m := range fitness[members[0]] {
+		sort.SliceStable(order, func(a, b int) bool {
+			return fitness[members[order[a]]][m] < fitness[members[order[b]]][m]
+		})
+		low, high := fitness[members[order[0]]][m], fitness[members[order[len(order)-1]]][m]
+		distances[order[0]] = math.Inf(1)
+		distances[order[len(order)-1]] = math.Inf(1)
+		if high == low {
+			continue
+		}
+		for k := 1; k < len(order)-1; k++ {
+			gap := fitness[members[order[k+1]]][m] - fitness[members[order[k-1]]][m]
+			distances[order[k]] += gap / (high - low)
+		}
+	}
+	return distances
+}
+
+// indices of the `size` best solutions, by front first and then by decreasing crowding distance
+func selectByRankAndCrowding(fitness []arrays.Array1D, size int) []int {
+	var selected []int
+	for _, front := range nonDominatedSort(fitness) {
+		if len(selected)+len(front) <= size {
+			selected = append(selected, front...)
+			continue
+		}
+		distances := crowdingDistances(fitness, front)
+		order := allIndices(len(front))
+		sort.SliceStable(order, func(a, b int) bool { return distances[order[a]] > distances[order[b]] })
+		for _, k := range order[:size-len(selected)] {
+			selected = append(selected, front[k])
+		}
+		break
+	}
+	return selected
+}
+
+func allIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
@@ -0,0 +1,107 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"math"
+	"math/rand"
+)
+
+// DefaultPBestRate is the fraction of the population `CurrentToPBestOne` picks
+// its "p-best" agent from when `P` is not set
+const DefaultPBestRate = 0.1
+
+// MutationStrategy builds the mutant (donor) vector for a given agent
+type MutationStrategy interface {
+	// Mutate returns the mutant for agent `referenceAgentNumber` using `weightingFactor` as F
+	Mutate(e *Evolver, referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D
+	// RandomAgents is how many distinct random agents, besides the reference one, the strategy draws
+	RandomAgents() int
+}
+
+// DE/rand/1: v = r1 + F*(r3 - r2)
+type RandOne struct{}
+
+func (RandOne) Mutate(e *Evolver, referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D {
+	r := e.pickRandomAgents(rng, referenceAgentNumber, 3)
+	return r[0].Add(r[2].Subtract(r[1]).MultiplyByConstant(weightingFactor))
+}
+
+func (RandOne) RandomAgents() int {
+	return 3
+}
+
+// DE/best/1: v = best + F*(r1 - r2)
+type BestOne struct{}
+
+func (BestOne) Mutate(e *Evolver, referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D {
+	r := e.pickRandomAgents(rng, referenceAgentNumber, 2)
+	return e.bestAgent().Add(r[0].Subtract(r[1]).MultiplyByConstant(weightingFactor))
+}
+
+func (BestOne) RandomAgents() int {
+	return 2
+}
+
+// DE/current-to-best/1: v = x + F*(best - x) + F*(r1 - r2)
+type CurrentToBestOne struct{}
+
+func (CurrentToBestOne) Mutate(e *Evolver, referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D {
+	x := e.Population.GetRow(referenceAgentNumber)
+	return currentToTarget(x, e.bestAgent(), e.pickRandomAgents(rng, referenceAgentNumber, 2), weightingFactor)
+}
+
+func (CurrentToBestOne) RandomAgents() int {
+	return 2
+}
+
+// DE/rand/2: v = r1 + F*(r2 - r3) + F*(r4 - r5)
+type RandTwo struct{}
+
+func (RandTwo) Mutate(e *Evolver, referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D {
+	r := e.pickRandomAgents(rng, referenceAgentNumber, 5)
+	difference := r[1].Subtract(r[2]).Add(r[3].Subtract(r[4]))
+	return r[0].Add(difference.MultiplyByConstant(weightingFactor))
+}
+
+func (RandTwo) RandomAgents() int {
+	return 5
+}
+
+// DE/best/2: v = best + F*(r1 - r2) + F*(r3 - r4)
+type BestTwo struct{}
+
+func (BestTwo) Mutate(e *Evolver, referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D {
+	r := e.pickRandomAgents(rng, referenceAgentNumber, 4)
+	difference := r[0].Subtract(r[1]).Add(r[2].Subtract(r[3]))
+	return e.bestAgent().Add(difference.MultiplyByConstant(weightingFactor))
+}
+
+func (BestTwo) RandomAgents() int {
+	return 4
+}
+
+// DE/current-to-pbest/1 (JADE, without archive): v = x + F*(pbest - x) + F*(r1 - r2)
+// `pbest` is picked at random from the best `P` fraction of the population
+type CurrentToPBestOne struct {
+	P float64
+}
+
+func (s CurrentToPBestOne) Mutate(e *Evolver, referenceAgentNumber int, weightingFactor float64, rng *rand.Rand) *arrays.Array1D {
+	p := s.P
+	if p <= 0 {
+		p = DefaultPBestRate
+	}
+	x := e.Population.GetRow(referenceAgentNumber)
+	pBest := e.pickRandomTopAgent(rng, int(math.Round(p*float64(e.PopulationSize))))
+	return currentToTarget(x, pBest, e.pickRandomAgents(rng, referenceAgentNumber, 2), weightingFactor)
+}
+
+func (CurrentToPBestOne) RandomAgents() int {
+	return 2
+}
+
+// v = x + F*(target - x) + F*(r[0] - r[1])
+func currentToTarget(x, target *arrays.Array1D, r []*arrays.Array1D, weightingFactor float64) *arrays.Array1D {
+	difference := target.Subtract(x).Add(r[0].Subtract(r[1]))
+	return x.Add(difference.MultiplyByConstant(weightingFactor))
+}
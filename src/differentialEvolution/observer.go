@@ -0,0 +1,100 @@
+package differentialEvolution
+
+import "arrays"
+
+// Snapshot is a copy of the evolver's state, which observers may keep and modify freely
+type Snapshot struct {
+	Generation  int
+	Evaluations int
+	Population  *arrays.Array2D
+	Fitness     arrays.Array1D
+	Violation   arrays.Array1D
+	BestAgent   *arrays.Array1D
+	BestFitness float64
+	// total constraint violation of `BestAgent`, 0 if it is feasible
+	BestViolation float64
+	StopReason    StopReason
+	Statistics    Statistics
+}
+
+// Observer is notified as the evolution goes, from the goroutine driving the evolver
+type Observer interface {
+	GenerationStarted(s Snapshot)
+	GenerationEnded(s Snapshot)
+	// NewBest is called whenever the best fitness improves, including by the initial population
+	NewBest(s Snapshot)
+	// Terminated is called once the termination criterion is met, or the run is cancelled
+	Terminated(s Snapshot)
+}
+
+// ObserverFuncs is an `Observer` made of optional functions, nil ones being skipped
+type ObserverFuncs struct {
+	OnGenerationStarted func(s Snapshot)
+	OnGenerationEnded   func(s Snapshot)
+	OnNewBest           func(s Snapshot)
+	OnTerminated        func(s Snapshot)
+}
+
+func (o ObserverFuncs) GenerationStarted(s Snapshot) {
+	if o.OnGenerationStarted != nil {
+		o.OnGenerationStarted(s)
+	}
+}
+
+func (o ObserverFuncs) GenerationEnded(s Snapshot) {
+	if o.OnGenerationEnded != nil {
+		o.OnGenerationEnded(s)
+	}
+}
+
+func (o ObserverFuncs) NewBest(s Snapshot) {
+	if o.OnNewBest != nil {
+		o.OnNewBest(s)
+	}
+}
+
+func (o ObserverFuncs) Terminated(s Snapshot) {
+	if o.OnTerminated != nil {
+		o.OnTerminated(s)
+	}
+}
+
+func (e *Evolver) AddObserver(o Observer) {
+	e.Observers = append(e.Observers, o)
+}
+
+func (e *Evolver) Snapshot() Snapshot {
+	s := Snapshot{
+		Generation:    e.CurrentGeneration,
+		Evaluations:   e.Evaluations,
+		Fitness:       *e.Fitness.Copy(),
+		Violation:     *e.Violation.Copy(),
+		BestFitness:   e.CurrentBestFitness,
+		BestViolation: e.CurrentBestViolation,
+		StopReason:    e.StopReason,
+		Statistics:    e.Statistics,
+	}
+	s.Statistics.GeneSpread = *e.Statistics.GeneSpread.Copy()
+	if e.Population != nil {
+		population := make(arrays.Array2D, e.Population.NRows())
+		for i := range population {
+			population[i] = e.Population.GetRow(i).Copy()
+		}
+		s.Population = &population
+	}
+	if e.CurrentBestAgent != nil {
+		s.BestAgent = e.CurrentBestAgent.Copy()
+	}
+	return s
+}
+
+// calls `notify` on every observer with a snapshot of the current state
+func (e *Evolver) notify(notify func(o Observer, s Snapshot)) {
+	if len(e.Observers) == 0 {
+		return
+	}
+	s := e.Snapshot()
+	for _, o := range e.Observers {
+		notify(o, s)
+	}
+}
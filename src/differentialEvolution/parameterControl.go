@@ -0,0 +1,179 @@
+package differentialEvolution
+
+import (
+	"log"
+	"math"
+	"math/rand"
+)
+
+// AgentParameters are the control parameters used to build an agent's trial vector
+type AgentParameters struct {
+	WeightingFactor float64
+	CrossoverRate   float64
+}
+
+// ParameterSuccess records the parameters of a trial vector that replaced its parent
+type ParameterSuccess struct {
+	Parameters  AgentParameters
+	Improvement float64 // parent fitness minus trial fitness
+}
+
+// ParameterControl decides the weighting factor and crossover rate of each trial vector
+type ParameterControl interface {
+	// Sample returns the parameters for an agent's next trial, given the ones the agent currently carries
+	Sample(current AgentParameters, rng *rand.Rand) AgentParameters
+	// Update is called at the end of every generation with the trials that improved on their parents
+	Update(successes []ParameterSuccess)
+}
+
+// FixedParameters keeps `WeightingFactor` and `CrossoverRate` constant for the whole run
+type FixedParameters struct{}
+
+func (FixedParameters) Sample(current AgentParameters, _ *rand.Rand) AgentParameters {
+	return current
+}
+
+func (FixedParameters) Update([]ParameterSuccess) {}
+
+// JDE is the self-adaptive scheme from Brest et al. (2006)
+// Each agent carries its own F and CR, which are regenerated with probability
+// `Tau1` and `Tau2` and kept only if the resulting trial survives
+type JDE struct {
+	Tau1   float64 // probability of regenerating F
+	Tau2   float64 // probability of regenerating CR
+	FLower float64
+	FUpper float64 // F is regenerated in [FLower, FLower+FUpper)
+}
+
+func NewJDE() *JDE {
+	return &JDE{
+		Tau1:   0.1,
+		Tau2:   0.1,
+		FLower: 0.1,
+		FUpper: 0.9,
+	}
+}
+
+func (c *JDE) Sample(current AgentParameters, rng *rand.Rand) AgentParameters {
+	if rng.Float64() < c.Tau1 {
+		current.WeightingFactor = c.FLower + rng.Float64()*c.FUpper
+	}
+	if rng.Float64() < c.Tau2 {
+		current.CrossoverRate = rng.Float64()
+	}
+	return current
+}
+
+func (c *JDE) Update([]ParameterSuccess) {}
+
+// JADE samples F from a Cauchy and CR from a normal distribution, centered on means
+// adapted from the successful parameters of each generation (Zhang and Sanderson, 2009)
+type JADE struct {
+	C                   float64 // adaptation rate of the means
+	MeanWeightingFactor float64
+	MeanCrossoverRate   float64
+}
+
+func NewJADE() *JADE {
+	return &JADE{
+		C:                   0.1,
+		MeanWeightingFactor: 0.5,
+		MeanCrossoverRate:   0.5,
+	}
+}
+
+func (c *JADE) Sample(_ AgentParameters, rng *rand.Rand) AgentParameters {
+	return sampleParameters(rng, c.MeanWeightingFactor, c.MeanCrossoverRate)
+}
+
+func (c *JADE) Update(successes []ParameterSuccess) {
+	if len(successes) == 0 {
+		return
+	}
+	weights := make([]float64, len(successes))
+	for i := range weights {
+		weights[i] = 1
+	}
+	f, cr := successMeans(successes, weights)
+	c.MeanWeightingFactor = (1-c.C)*c.MeanWeightingFactor + c.C*f
+	c.MeanCrossoverRate = (1-c.C)*c.MeanCrossoverRate + c.C*cr
+}
+
+// SHADE keeps a circular memory of `MemorySize` successful parameter means; each trial
+// samples around a random memory entry (Tanabe and Fukunaga, 2013)
+type SHADE struct {
+	WeightingFactorMemory []float64
+	CrossoverRateMemory   []float64
+	Next                  int // memory position overwritten by the next update
+}
+
+func NewSHADE(memorySize int) *SHADE {
+	if memorySize <= 0 {
+		log.Fatalf("SHADE memory size must be positive, got %d", memorySize)
+	}
+	c := &SHADE{
+		WeightingFactorMemory: make([]float64, memorySize),
+		CrossoverRateMemory:   make([]float64, memorySize),
+	}
+	for i := 0; i < memorySize; i++ {
+		c.WeightingFactorMemory[i] = 0.5
+		c.CrossoverRateMemory[i] = 0.5
+	}
+	return c
+}
+
+func (c *SHADE) Sample(_ AgentParameters, rng *rand.Rand) AgentParameters {
+	r := rng.Intn(len(c.WeightingFactorMemory))
+	return sampleParameters(rng, c.WeightingFactorMemory[r], c.CrossoverRateMemory[r])
+}
+
+func (c *SHADE) Update(successes []ParameterSuccess) {
+	if len(successes) == 0 {
+		return
+	}
+	// means are weighted by how much each trial improved on its parent
+	weights := make([]float64, len(successes))
+	for i, s := range successes {
+		weights[i] = s.Improvement
+	}
+	f, cr := successMeans(successes, weights)
+	c.WeightingFactorMemory[c.Next] = f
+	c.CrossoverRateMemory[c.Next] = cr
+	c.Next = (c.Next + 1) % len(c.WeightingFactorMemory)
+}
+
+// F ~ Cauchy(meanF, 0.1), regenerated while not positive and truncated to 1
+// CR ~ Normal(meanCR, 0.1), truncated to [0, 1]
+func sampleParameters(rng *rand.Rand, meanF, meanCR float64) AgentParameters {
+	f := 0.0
+	for f <= 0 {
+		f = meanF + 0.1*math.Tan(math.Pi*(rng.Float64()-0.5))
+	}
+	cr := meanCR + 0.1*rng.NormFloat64()
+	return AgentParameters{
+		WeightingFactor: math.Min(f, 1),
+		CrossoverRate:   math.Max(0, math.Min(cr, 1)),
+	}
+}
+
+// weighted Lehmer mean of F and weighted arithmetic mean of CR
+func successMeans(successes []ParameterSuccess, weights []float64) (float64, float64) {
+	totalWeight := 0.0
+	for _, w := range weights {
+		totalWeight += w
+	}
+	if totalWeight <= 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		totalWeight = float64(len(weights))
+	}
+	var sumF, sumFSquared, sumCR float64
+	for i, s := range successes {
+		w := weights[i] / totalWeight
+		sumF += w * s.Parameters.WeightingFactor
+		sumFSquared += w * s.Parameters.WeightingFactor * s.Parameters.WeightingFactor
+		sumCR += w * s.Parameters.CrossoverRate
+	}
+	return sumFSquared / sumF, sumCR
+}
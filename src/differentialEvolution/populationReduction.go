@@ -0,0 +1,47 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"math"
+)
+
+// size the population should have after `Evaluations` function evaluations,
+// shrinking linearly from the initial size to `MinPopulationSize` at `MaxEvaluations` (L-SHADE)
+func (e *Evolver) plannedPopulationSize() int {
+	progress := math.Min(1, float64(e.Evaluations)/float64(e.MaxEvaluations))
+	size := float64(e.initialPopulationSize) + float64(e.MinPopulationSize-e.initialPopulationSize)*progress
+	return int(math.Max(float64(e.MinPopulationSize), math.Round(size)))
+}
+
+// drops the worst agents until the population matches the planned size
+func (e *Evolver) reducePopulation() {
+	if e.MinPopulationSize <= 0 {
+		return
+	}
+	size := e.plannedPopulationSize()
+	if size >= e.PopulationSize {
+		return
+	}
+	e.keepBest(size)
+}
+
+// drops the worst agents, keeping the `size` fittest
+func (e *Evolver) keepBest(size int) {
+	e.rankPopulation()
+	population := make(arrays.Array2D, size)
+	fitness := make(arrays.Array1D, size)
+	violation := make(arrays.Array1D, size)
+	parameters := make([]AgentParameters, size)
+	for i, n := range e.ranking[:size] {
+		population[i] = e.Population.GetRow(n)
+		fitness[i] = e.Fitness.Get(n)
+		violation[i] = e.Violation.Get(n)
+		parameters[i] = e.parameters[n]
+	}
+	e.Population = &population
+	e.Fitness = fitness
+	e.Violation = violation
+	e.parameters = parameters
+	e.PopulationSize = size
+	e.rankPopulation()
+}
@@ -0,0 +1,208 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"context"
+	"math"
+	"utils"
+)
+
+// RestartPolicy reinitializes the population when the evolution stalls or loses its diversity,
+// instead of stopping it
+type RestartPolicy interface {
+	// Restart replaces agents of `e.Population`, returning the numbers of the agents it replaced
+	// The population may also be resized; the best agent found so far is kept by the evolver
+	Restart(e *Evolver) []int
+}
+
+// RestartRecord describes a restart that happened during the evolution
+type RestartRecord struct {
+	Generation     int
+	Evaluations    int
+	Reason         StopReason // criterion that would have stopped the evolution
+	BestFitness    float64
+	PopulationSize int // after the restart
+}
+
+// IPOPRestart reinitializes the whole population with `Factor` times as many agents,
+// up to `MaxPopulationSize` if it is greater than 0
+// Population size reduction, if enabled, shrinks it back to its planned size
+type IPOPRestart struct {
+	Factor            float64 // defaults to 2
+	MaxPopulationSize int
+}
+
+func (r IPOPRestart) Restart(e *Evolver) []int {
+	factor := r.Factor
+	if factor <= 0 {
+		factor = 2
+	}
+	size := int(math.Round(float64(e.PopulationSize) * factor))
+	if r.MaxPopulationSize > 0 && size > r.MaxPopulationSize {
+		size = r.MaxPopulationSize
+	}
+	e.resizePopulation(size)
+	replaced := make([]int, size)
+	for i := range replaced {
+		(*e.Population)[i] = e.randomAgent()
+		replaced[i] = i
+	}
+	return replaced
+}
+
+// PartialRestart reinitializes the worst `Fraction` (0~1, excluding 0) of the population, at
+// least one agent
+type PartialRestart struct {
+	Fraction float64
+}
+
+func (r PartialRestart) Restart(e *Evolver) []int {
+	e.rankPopulation()
+	count := int(math.Round(r.Fraction * float64(e.PopulationSize)))
+	count = int(math.Max(1, math.Min(float64(count), float64(e.PopulationSize))))
+	replaced := e.ranking[e.PopulationSize-count:]
+	for _, n := range replaced {
+		(*e.Population)[n] = e.randomAgent()
+	}
+	return replaced
+}
+
+// LocalRestart reinitializes the population, but for its best agent, around the best agent found
+// so far, within `Radius` (0~1) of the width of each feature's search space
+type LocalRestart struct {
+	Radius float64
+}
+
+func (r LocalRestart) Restart(e *Evolver) []int {
+	e.rankPopulation()
+	replaced := append([]int(nil), e.ranking[1:]...)
+	for _, n := range replaced {
+		agent := make(arrays.Array1D, e.AgentSize)
+		for j, space := range e.SearchSpace {
+			radius := r.Radius * (space.UpperBound - space.LowerBound)
+			center := e.CurrentBestAgent.Get(j)
+			value := utils.RandomInRange(e.Rand, utils.Range1D{
+				LowerBound: math.Max(space.LowerBound, center-radius),
+				UpperBound: math.Min(space.UpperBound, center+radius),
+			})
+			agent.Set(j, utils.ConstrainValue(value, space))
+		}
+		(*e.Population)[n] = &agent
+	}
+	return replaced
+}
+
+// OppositionRestart moves every agent to its opposite point within the search space, and
+// reinitializes the agents whose opposite falls within the region the population converged to,
+// e.g. those of a population that converged around the center of the search space
+// The region is the box the population spans, widened on each side by `Margin` (0~1, defaults
+// to 0.1) of the width of each feature's search space
+type OppositionRestart struct {
+	Margin float64
+}
+
+func (r OppositionRestart) Restart(e *Evolver) []int {
+	margin := r.Margin
+	if margin <= 0 {
+		margin = 0.1
+	}
+	low := make(arrays.Array1D, e.AgentSize)
+	high := make(arrays.Array1D, e.AgentSize)
+	for j, space := range e.SearchSpace {
+		low[j], high[j] = math.Inf(1), math.Inf(-1)
+		for _, agent := range *e.Population {
+			low[j] = math.Min(low[j], agent.Get(j))
+			high[j] = math.Max(high[j], agent.Get(j))
+		}
+		width := space.UpperBound - space.LowerBound
+		low[j] -= margin * width
+		high[j] += margin * width
+	}
+	replaced := make([]int, e.PopulationSize)
+	for i := range replaced {
+		agent := e.Population.GetRow(i).Copy()
+		inRegion := true
+		for j, space := range e.SearchSpace {
+			opposite := utils.ConstrainValue(space.LowerBound+space.UpperBound-agent.Get(j), space)
+			inRegion = inRegion && opposite >= low[j] && opposite <= high[j]
+			agent.Set(j, opposite)
+		}
+		if inRegion {
+			agent = e.randomAgent()
+		}
+		(*e.Population)[i] = agent
+		replaced[i] = i
+	}
+	return replaced
+}
+
+// whether the evolution should restart instead of stopping for `reason`
+func (e *Evolver) shouldRestart(reason StopReason) bool {
+	if e.Restart == nil || (reason != FitnessStalled && reason != DiversityCollapsed) {
+		return false
+	}
+	return e.MaxRestarts <= 0 || len(e.Restarts) < e.MaxRestarts
+}
+
+func (e *Evolver) restart(ctx context.Context, reason StopReason) {
+	replaced := e.Restart.Restart(e)
+	for _, n := range replaced {
+		e.parameters[n] = e.initialParameters()
+	}
+	// unless an agent that was kept is as good, the best agent found so far takes the place of
+	// the first replaced agent, so it is never lost
+	if len(replaced) > 0 && !e.keptBest(replaced) {
+		(*e.Population)[replaced[0]] = e.CurrentBestAgent.Copy()
+		e.Fitness.Set(replaced[0], e.CurrentBestFitness)
+		e.Violation.Set(replaced[0], e.CurrentBestViolation)
+		replaced = replaced[1:]
+	}
+	e.evaluateAgents(ctx, replaced)
+	e.BestFitnessHistory = []float64{e.CurrentBestFitness}
+	e.Restarts = append(e.Restarts, RestartRecord{
+		Generation:     e.CurrentGeneration,
+		Evaluations:    e.Evaluations,
+		Reason:         reason,
+		BestFitness:    e.CurrentBestFitness,
+		PopulationSize: e.PopulationSize,
+	})
+}
+
+// whether an agent not in `replaced` ties with the best agent found so far
+func (e *Evolver) keptBest(replaced []int) bool {
+	isReplaced := make(map[int]bool, len(replaced))
+	for _, n := range replaced {
+		isReplaced[n] = true
+	}
+	for n := 0; n < e.PopulationSize; n++ {
+		if !isReplaced[n] && e.isNewBest(e.Fitness.Get(n), e.Violation.Get(n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// grows or shrinks the population, keeping the first agents
+// Agents added are empty and must be set by the caller
+func (e *Evolver) resizePopulation(size int) {
+	population := make(arrays.Array2D, size)
+	fitness := make(arrays.Array1D, size)
+	violation := make(arrays.Array1D, size)
+	parameters := make([]AgentParameters, size)
+	for i := 0; i < size; i++ {
+		if i < e.PopulationSize {
+			population[i] = e.Population.GetRow(i)
+			fitness[i] = e.Fitness.Get(i)
+			violation[i] = e.Violation.Get(i)
+			parameters[i] = e.parameters[i]
+		} else {
+			population[i] = &arrays.Array1D{}
+			parameters[i] = e.initialParameters()
+		}
+	}
+	e.Population = &population
+	e.Fitness = fitness
+	e.Violation = violation
+	e.parameters = parameters
+	e.PopulationSize = size
+}
@@ -0,0 +1,64 @@
+package differentialEvolution
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestRestartsKeepTheBestAgent(t *testing.T) {
+	policies := map[string]RestartPolicy{
+		"ipop":        IPOPRestart{},
+		"partial":     PartialRestart{Fraction: 1},
+		"local":       LocalRestart{Radius: 0.1},
+		"opposition":  OppositionRestart{},
+		"partial one": PartialRestart{Fraction: 0.01},
+	}
+	for name, policy := range policies {
+		p := seededParams(1, 30)
+		p.Restart = policy
+		e := NewEvolver(p)
+		runToEnd(t, &e)
+		bestFitness, bestAgent := e.CurrentBestFitness, e.CurrentBestAgent.Copy()
+		e.restart(context.Background(), FitnessStalled)
+		if e.CurrentBestFitness > bestFitness {
+			t.Errorf("%s: best fitness went from %v to %v", name, bestFitness, e.CurrentBestFitness)
+		}
+		kept := false
+		for i := 0; i < e.PopulationSize; i++ {
+			kept = kept || e.Fitness.Get(i) <= bestFitness
+		}
+		if !kept {
+			t.Errorf("%s: no agent of the population is as fit as the best one %v (%v)", name, bestAgent, bestFitness)
+		}
+		if len(e.Restarts) != 1 {
+			t.Errorf("%s: %d restarts recorded, expected 1", name, len(e.Restarts))
+		}
+	}
+}
+
+func TestOppositionRestartLeavesAConvergedRegion(t *testing.T) {
+	e := NewEvolver(seededParams(1, 30))
+	e.InitializePopulation()
+	// a population converged on the center of the search space, which is its own opposite
+	for i := 0; i < e.PopulationSize; i++ {
+		for j := 0; j < e.AgentSize; j++ {
+			e.Population.SetValue(i, j, 1e-6*float64(i))
+		}
+	}
+	e.evaluatePopulation(context.Background())
+	OppositionRestart{}.Restart(&e)
+	// by more than the default margin, 0.1 of the width of the search space
+	moved := 0
+	for i := 0; i < e.PopulationSize; i++ {
+		for j := 0; j < e.AgentSize; j++ {
+			if math.Abs(e.Population.GetValue(i, j)) > 1 {
+				moved++
+				break
+			}
+		}
+	}
+	if moved != e.PopulationSize {
+		t.Errorf("%d agents of %d left the converged region", moved, e.PopulationSize)
+	}
+}
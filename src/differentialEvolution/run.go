@@ -0,0 +1,65 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"context"
+)
+
+// Result is the outcome of `Evolver.Run`
+type Result struct {
+	BestAgent   *arrays.Array1D
+	BestFitness float64
+	Generations int
+	Evaluations int
+	StopReason  StopReason
+	Restarts    []RestartRecord
+	// how much local refinement improved the best fitness, included in `BestFitness`
+	Refinement RefinementStats
+}
+
+// Run evolves the population until the termination criterion is met or `ctx` ends,
+// initializing it first if needed
+// Cancellation and deadlines are not errors: the best agent found so far is returned,
+// with `Cancelled` or `DeadlineExceeded` as the stop reason, and without final refinement
+// They are also checked during initialization and restarts, so the best agent may be nil if
+// `ctx` ends before any agent is evaluated
+func (e *Evolver) Run(ctx context.Context) (Result, error) {
+	if e.Population == nil {
+		e.initializePopulation(ctx)
+	}
+	for {
+		if reason := contextStopReason(ctx); reason != NotStopped {
+			e.stop(reason)
+			break
+		}
+		if !e.shouldContinue(ctx) {
+			break
+		}
+		if err := e.evolve(ctx); err != nil {
+			return e.result(), err
+		}
+	}
+	return e.result(), nil
+}
+
+func (e *Evolver) result() Result {
+	return Result{
+		BestAgent:   e.CurrentBestAgent,
+		BestFitness: e.CurrentBestFitness,
+		Generations: e.CurrentGeneration,
+		Evaluations: e.Evaluations,
+		StopReason:  e.StopReason,
+		Restarts:    e.Restarts,
+		Refinement:  e.Refinement,
+	}
+}
+
+func contextStopReason(ctx context.Context) StopReason {
+	switch ctx.Err() {
+	case context.Canceled:
+		return Cancelled
+	case context.DeadlineExceeded:
+		return DeadlineExceeded
+	}
+	return NotStopped
+}
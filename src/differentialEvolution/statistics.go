@@ -0,0 +1,86 @@
+package differentialEvolution
+
+import (
+	"arrays"
+	"math"
+	"sort"
+	"utils"
+)
+
+// Statistics describe the fitness and diversity of a population
+type Statistics struct {
+	BestFitness   float64
+	MeanFitness   float64
+	MedianFitness float64
+	WorstFitness  float64
+	FitnessStdDev float64
+	// range of each feature across the population, relative to the width of its search space (0~1)
+	GeneSpread arrays.Array1D
+	// mean of `GeneSpread`, leaving out features whose search space has no width
+	MeanGeneSpread       float64
+	MeanPairwiseDistance float64 // euclidean distance between agents, over every pair of them
+}
+
+func ComputeStatistics(population *arrays.Array2D, fitness arrays.Array1D, searchSpace []utils.Range1D) Statistics {
+	var s Statistics
+	n := fitness.Length()
+	if n == 0 {
+		return s
+	}
+	sorted := *fitness.Copy()
+	sort.Float64s(sorted)
+	s.BestFitness = sorted[0]
+	s.WorstFitness = sorted[n-1]
+	if n%2 == 1 {
+		s.MedianFitness = sorted[n/2]
+	} else {
+		s.MedianFitness = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	s.MeanFitness = fitness.Sum() / float64(n)
+	variance := 0.0
+	for _, f := range fitness.Items() {
+		variance += (f - s.MeanFitness) * (f - s.MeanFitness)
+	}
+	s.FitnessStdDev = math.Sqrt(variance / float64(n))
+
+	s.GeneSpread = make(arrays.Array1D, len(searchSpace))
+	features := 0
+	for j, space := range searchSpace {
+		width := space.UpperBound - space.LowerBound
+		if width <= 0 {
+			continue
+		}
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, agent := range *population {
+			low = math.Min(low, agent.Get(j))
+			high = math.Max(high, agent.Get(j))
+		}
+		s.GeneSpread.Set(j, (high-low)/width)
+		s.MeanGeneSpread += s.GeneSpread.Get(j)
+		features++
+	}
+	if features > 0 {
+		s.MeanGeneSpread /= float64(features)
+	}
+
+	pairs := 0
+	for i := 0; i < population.NRows(); i++ {
+		for k := i + 1; k < population.NRows(); k++ {
+			difference := population.GetRow(i).Subtract(population.GetRow(k))
+			distance := 0.0
+			for _, d := range difference.Items() {
+				distance += d * d
+			}
+			s.MeanPairwiseDistance += math.Sqrt(distance)
+			pairs++
+		}
+	}
+	if pairs > 0 {
+		s.MeanPairwiseDistance /= float64(pairs)
+	}
+	return s
+}
+
+func (e *Evolver) updateStatistics() {
+	e.Statistics = ComputeStatistics(e.Population, e.Fitness, e.SearchSpace)
+}
@@ -0,0 +1,189 @@
+package differentialEvolution
+
+import (
+	"math"
+	"time"
+)
+
+// StopReason tells which termination criterion ended the evolution
+type StopReason int
+
+const (
+	NotStopped StopReason = iota
+	MaxGenerationsReached
+	MaxEvaluationsReached
+	TimeBudgetExhausted
+	DiversityCollapsed
+	FitnessStalled
+	TargetFitnessReached
+	Cancelled
+	DeadlineExceeded
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case NotStopped:
+		return "not stopped"
+	case MaxGenerationsReached:
+		return "max generations reached"
+	case MaxEvaluationsReached:
+		return "max evaluations reached"
+	case TimeBudgetExhausted:
+		return "time budget exhausted"
+	case DiversityCollapsed:
+		return "population diversity collapsed"
+	case FitnessStalled:
+		return "evolution stalled"
+	case TargetFitnessReached:
+		return "target fitness reached"
+	case Cancelled:
+		return "cancelled"
+	case DeadlineExceeded:
+		return "deadline exceeded"
+	}
+	return "unknown"
+}
+
+type TerminationCriterion interface {
+	// Check returns `NotStopped` while the evolution should go on
+	Check(e *Evolver) StopReason
+}
+
+// GenerationLimit stops after `Max` generations
+type GenerationLimit struct {
+	Max int
+}
+
+func (c GenerationLimit) Check(e *Evolver) StopReason {
+	if e.CurrentGeneration >= c.Max {
+		return MaxGenerationsReached
+	}
+	return NotStopped
+}
+
+// EvaluationLimit stops once the fitness function has been called `Max` times
+type EvaluationLimit struct {
+	Max int
+}
+
+func (c EvaluationLimit) Check(e *Evolver) StopReason {
+	if e.Evaluations >= c.Max {
+		return MaxEvaluationsReached
+	}
+	return NotStopped
+}
+
+// TimeBudget stops once `Budget` has passed since the population was initialized
+type TimeBudget struct {
+	Budget time.Duration
+}
+
+func (c TimeBudget) Check(e *Evolver) StopReason {
+	if time.Since(e.StartedAt) >= c.Budget {
+		return TimeBudgetExhausted
+	}
+	return NotStopped
+}
+
+// FitnessTarget stops once the best fitness is less than or equal to `Target`,
+// and the best agent satisfies the constraints
+type FitnessTarget struct {
+	Target float64
+}
+
+func (c FitnessTarget) Check(e *Evolver) StopReason {
+	if e.CurrentBestFitness <= c.Target && e.CurrentBestViolation <= 0 {
+		return TargetFitnessReached
+	}
+	return NotStopped
+}
+
+// FitnessStall stops if the best fitness improved in a proportion less than `Factor`
+// to the previous best fitness for each of the last `Period` generations
+type FitnessStall struct {
+	Period int
+	Factor float64
+}
+
+func (c FitnessStall) Check(e *Evolver) StopReason {
+	history := e.BestFitnessHistory
+	if len(history) <= c.Period {
+		return NotStopped
+	}
+	for i := len(history) - c.Period; i < len(history); i++ {
+		improvementRatio := (history[i-1] - history[i]) / history[i-1]
+		// a 0/0 ratio means the fitness was already 0, which is not a stall
+		if math.IsNaN(improvementRatio) || improvementRatio > c.Factor {
+			return NotStopped
+		}
+	}
+	return FitnessStalled
+}
+
+// DiversityCollapse stops once the population's `MeanGeneSpread` is less than `Threshold` (0~1)
+type DiversityCollapse struct {
+	Threshold float64
+}
+
+func (c DiversityCollapse) Check(e *Evolver) StopReason {
+	if e.Population != nil && e.Statistics.MeanGeneSpread < c.Threshold {
+		return DiversityCollapsed
+	}
+	return NotStopped
+}
+
+type anyCriterion []TerminationCriterion
+
+// Any stops as soon as one of `criteria` does, with its reason
+func Any(criteria ...TerminationCriterion) TerminationCriterion {
+	return anyCriterion(criteria)
+}
+
+func (c anyCriterion) Check(e *Evolver) StopReason {
+	for _, criterion := range c {
+		if reason := criterion.Check(e); reason != NotStopped {
+			return reason
+		}
+	}
+	return NotStopped
+}
+
+type allCriteria []TerminationCriterion
+
+// All stops only when every one of `criteria` does, with the reason of the first
+func All(criteria ...TerminationCriterion) TerminationCriterion {
+	return allCriteria(criteria)
+}
+
+func (c allCriteria) Check(e *Evolver) StopReason {
+	reason := NotStopped
+	for _, criterion := range c {
+		r := criterion.Check(e)
+		if r == NotStopped {
+			return NotStopped
+		}
+		if reason == NotStopped {
+			reason = r
+		}
+	}
+	return reason
+}
+
+// criterion built from `MaxGenerations`, `MaxEvaluations`, `TargetFitness` and `StallPeriod`,
+// used when no `Termination` is given
+func (e *Evolver) defaultTermination() TerminationCriterion {
+	var criteria []TerminationCriterion
+	if e.MaxGenerations > 0 {
+		criteria = append(criteria, GenerationLimit{e.MaxGenerations})
+	}
+	if e.MaxEvaluations > 0 {
+		criteria = append(criteria, EvaluationLimit{e.MaxEvaluations})
+	}
+	if e.TargetFitness >= 0 {
+		criteria = append(criteria, FitnessTarget{e.TargetFitness})
+	}
+	if e.StallPeriod > 0 {
+		criteria = append(criteria, FitnessStall{e.StallPeriod, e.StallFactor})
+	}
+	return Any(criteria...)
+}
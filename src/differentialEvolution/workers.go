@@ -0,0 +1,53 @@
+package differentialEvolution
+
+import (
+	"context"
+	"sync"
+)
+
+// FitnessFunctionFactory builds a fitness function for a single worker
+// Every worker calls it once, so state the fitness function mutates (e.g. a kinematic model)
+// can be owned by that worker instead of shared between goroutines
+type FitnessFunctionFactory func() FitnessFunction
+
+func (e *Evolver) buildFitnessFunctions() {
+	e.fitnessFunctions = make([]FitnessFunction, e.Workers)
+	for i := range e.fitnessFunctions {
+		if e.FitnessFunctionFactory != nil {
+			e.fitnessFunctions[i] = e.FitnessFunctionFactory()
+		} else {
+			e.fitnessFunctions[i] = e.FitnessFunction
+		}
+	}
+}
+
+// runs `task` for every agent number in [0, n) on a pool of `Workers` goroutines,
+// returning once all of them are done, or once the running ones are done if `ctx` ends first
+// `worker` identifies the goroutine running the task, and indexes its fitness function
+func (e *Evolver) forEachAgent(ctx context.Context, n int, task func(worker, agentNumber int)) {
+	forEachAgent(ctx, e.Workers, n, task)
+}
+
+func forEachAgent(ctx context.Context, workers, n int, task func(worker, agentNumber int)) {
+	agentNumbers := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for agentNumber := range agentNumbers {
+				task(worker, agentNumber)
+			}
+		}(w)
+	}
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case agentNumbers <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(agentNumbers)
+	wg.Wait()
+}
@@ -0,0 +1,232 @@
+package optimizer
+
+import (
+	"arrays"
+	"context"
+	de "differentialEvolution"
+	"fmt"
+	"math"
+	"sort"
+	"utils"
+)
+
+// CMAES is the covariance matrix adaptation evolution strategy (Hansen, "The CMA Evolution
+// Strategy: A Tutorial"), with `PopulationSize` as λ
+// The search runs on the search space scaled to [0, 1] on every feature; samples out of
+// bounds are brought back following each range's policy, with the mean as parent
+type CMAES struct {
+	Settings
+	Sigma float64 // initial step size, as a fraction of each feature's range, defaults to 0.3
+}
+
+func (o *CMAES) Optimize(ctx context.Context, p Problem) (Result, error) {
+	if err := o.validate(p); err != nil {
+		return Result{}, err
+	}
+	n := len(p.SearchSpace)
+	s := o.Settings
+	s.applyDefaults(4 + int(3*math.Log(float64(n))))
+	sigma := o.Sigma
+	if sigma <= 0 {
+		sigma = 0.3
+	}
+	lambda := s.PopulationSize
+	if lambda < 2 {
+		return Result{}, fmt.Errorf("population size %d too small, CMA-ES needs at least 2", lambda)
+	}
+	mu := lambda / 2
+	weights := make([]float64, mu)
+	weightSum, squaredWeightSum := 0.0, 0.0
+	for i := range weights {
+		weights[i] = math.Log(float64(mu)+0.5) - math.Log(float64(i+1))
+		weightSum += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= weightSum
+		squaredWeightSum += weights[i] * weights[i]
+	}
+	muEff := 1 / squaredWeightSum
+	nf := float64(n)
+	cc := (4 + muEff/nf) / (nf + 4 + 2*muEff/nf)
+	cs := (muEff + 2) / (nf + muEff + 5)
+	c1 := 2 / ((nf+1.3)*(nf+1.3) + muEff)
+	cmu := math.Min(1-c1, 2*(muEff-2+1/muEff)/((nf+2)*(nf+2)+muEff))
+	damps := 1 + 2*math.Max(0, math.Sqrt((muEff-1)/(nf+1))-1) + cs
+	chiN := math.Sqrt(nf) * (1 - 1/(4*nf) + 1/(21*nf*nf))
+
+	// the mean starts at the first initial agent, if any, or at random
+	mean := make([]float64, n)
+	for j, space := range p.SearchSpace {
+		if len(s.InitialAgents) > 0 {
+			mean[j] = toUnit(utils.ConstrainValue(s.InitialAgents[0].Get(j), space), space)
+		} else {
+			mean[j] = s.Rand.Float64()
+		}
+	}
+	pc, ps := make([]float64, n), make([]float64, n)
+	c := identity(n)
+	eigenvectors, eigenvalues := identity(n), make([]float64, n)
+	for i := range eigenvalues {
+		eigenvalues[i] = 1
+	}
+
+	t := newTracker(s)
+	ev := newEvaluator(p, s.Workers)
+	for {
+		if reason := t.stopReason(ctx); reason != de.NotStopped {
+			return t.result(reason), nil
+		}
+		// x = m + σ*B*D*z
+		agents := make([]*arrays.Array1D, lambda)
+		steps := make([][]float64, lambda)
+		for k := range agents {
+			z := make([]float64, n)
+			for i := range z {
+				z[i] = s.Rand.NormFloat64() * math.Sqrt(eigenvalues[i])
+			}
+			step := multiply(eigenvectors, z)
+			agent := make(arrays.Array1D, n)
+			for j, space := range p.SearchSpace {
+				value := fromUnit(mean[j]+sigma*step[j], space)
+				agent[j] = utils.HandleBounds(s.Rand, value, fromUnit(mean[j], space), space)
+				step[j] = (toUnit(agent[j], space) - mean[j]) / sigma
+			}
+			agents[k], steps[k] = &agent, step
+		}
+		fitness := ev.evaluate(agents)
+		for k, agent := range agents {
+			t.observe(agent, fitness[k])
+		}
+		order := make([]int, lambda)
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(a, b int) bool { return fitness[order[a]] < fitness[order[b]] })
+
+		// weighted mean of the best steps, y_w
+		meanStep := make([]float64, n)
+		for i := 0; i < mu; i++ {
+			for j := range meanStep {
+				meanStep[j] += weights[i] * steps[order[i]][j]
+			}
+		}
+		for j := range mean {
+			mean[j] += sigma * meanStep[j]
+		}
+		// C^(-1/2)*y_w = B*D^(-1)*B'*y_w
+		whitened := multiply(transpose(eigenvectors), meanStep)
+		for i := range whitened {
+			whitened[i] /= math.Sqrt(eigenvalues[i])
+		}
+		whitened = multiply(eigenvectors, whitened)
+		psNorm := 0.0
+		for j := range ps {
+			ps[j] = (1-cs)*ps[j] + math.Sqrt(cs*(2-cs)*muEff)*whitened[j]
+			psNorm += ps[j] * ps[j]
+		}
+		psNorm = math.Sqrt(psNorm)
+		hSigma := 0.0
+		if psNorm/math.Sqrt(1-math.Pow(1-cs, 2*float64(t.iteration+1)))/chiN < 1.4+2/(nf+1) {
+			hSigma = 1
+		}
+		for j := range pc {
+			pc[j] = (1-cc)*pc[j] + hSigma*math.Sqrt(cc*(2-cc)*muEff)*meanStep[j]
+		}
+		for a := 0; a < n; a++ {
+			for b := 0; b < n; b++ {
+				rankMu := 0.0
+				for i := 0; i < mu; i++ {
+					rankMu += weights[i] * steps[order[i]][a] * steps[order[i]][b]
+				}
+				rankOne := pc[a]*pc[b] + (1-hSigma)*cc*(2-cc)*c[a][b]
+				c[a][b] = (1-c1-cmu)*c[a][b] + c1*rankOne + cmu*rankMu
+			}
+		}
+		sigma *= math.Exp((cs / damps) * (psNorm/chiN - 1))
+		eigenvectors, eigenvalues = symmetricEigen(c)
+		for i := range eigenvalues {
+			// keeps the distribution from degenerating due to rounding errors
+			eigenvalues[i] = math.Max(eigenvalues[i], 1e-20)
+		}
+		t.endIteration()
+	}
+}
+
+func identity(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+		m[i][i] = 1
+	}
+	return m
+}
+
+func transpose(m [][]float64) [][]float64 {
+	t := make([][]float64, len(m[0]))
+	for i := range t {
+		t[i] = make([]float64, len(m))
+		for j := range m {
+			t[i][j] = m[j][i]
+		}
+	}
+	return t
+}
+
+func multiply(m [][]float64, v []float64) []float64 {
+	result := make([]float64, len(m))
+	for i, row := range m {
+		for j, value := range row {
+			result[i] += value * v[j]
+		}
+	}
+	return result
+}
+
+// eigenvectors (as columns) and eigenvalues of the symmetric matrix `m`, using Jacobi rotations
+func symmetricEigen(m [][]float64) ([][]float64, []float64) {
+	n := len(m)
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = append([]float64(nil), m[i]...)
+	}
+	v := identity(n)
+	for sweep := 0; sweep < 100; sweep++ {
+		offDiagonal := 0.0
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				offDiagonal += a[p][q] * a[p][q]
+			}
+		}
+		if offDiagonal < 1e-30 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				cos := 1 / math.Sqrt(t*t+1)
+				sin := t * cos
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = cos*akp-sin*akq, sin*akp+cos*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = cos*apk-sin*aqk, sin*apk+cos*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = cos*vkp-sin*vkq, sin*vkp+cos*vkq
+				}
+			}
+		}
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = a[i][i]
+	}
+	return v, values
+}
@@ -0,0 +1,82 @@
+package optimizer
+
+import (
+	"context"
+	de "differentialEvolution"
+	"fmt"
+)
+
+// DifferentialEvolution adapts `de.Evolver` to the `Optimizer` interface
+// An iteration is a generation
+type DifferentialEvolution struct {
+	Settings
+	CrossoverRate    float64             // 0~1, 0.5 when built by `New`
+	WeightingFactor  float64             // greater than 0, 0.5 when built by `New`
+	Mutation         de.MutationStrategy // defaults to `de.RandOne`
+	ParameterControl de.ParameterControl // defaults to `de.FixedParameters`
+	Initialization   de.Initializer      // defaults to `de.UniformInitializer`, seeded with `InitialAgents`
+}
+
+const DefaultDEPopulationSize = 10
+
+func (o *DifferentialEvolution) Optimize(ctx context.Context, p Problem) (Result, error) {
+	if err := o.validate(p); err != nil {
+		return Result{}, err
+	}
+	if o.CrossoverRate < 0 || o.CrossoverRate > 1 {
+		return Result{}, fmt.Errorf("crossover rate %v outside of [0, 1]", o.CrossoverRate)
+	}
+	if o.WeightingFactor <= 0 {
+		return Result{}, fmt.Errorf("weighting factor %v must be greater than 0", o.WeightingFactor)
+	}
+	s := o.Settings
+	s.applyDefaults(DefaultDEPopulationSize)
+	initialization := o.Initialization
+	if len(s.InitialAgents) > 0 {
+		initialization = de.SeededInitializer{Agents: s.InitialAgents, Base: initialization}
+	}
+	if err := de.ValidateInitializer(initialization, p.SearchSpace); err != nil {
+		return Result{}, err
+	}
+	var observers []de.Observer
+	if s.OnIteration != nil {
+		observers = append(observers, de.ObserverFuncs{
+			OnGenerationEnded: func(snapshot de.Snapshot) {
+				s.OnIteration(Progress{
+					Iteration:   snapshot.Generation,
+					Evaluations: snapshot.Evaluations,
+					BestAgent:   snapshot.BestAgent,
+					BestFitness: snapshot.BestFitness,
+				})
+			},
+		})
+	}
+	evolver := de.NewEvolver(de.NewEvolverParams{
+		AgentSize:              len(p.SearchSpace),
+		PopulationSize:         s.PopulationSize,
+		CrossoverRate:          o.CrossoverRate,
+		WeightingFactor:        o.WeightingFactor,
+		SearchSpace:            p.SearchSpace,
+		Mutation:               o.Mutation,
+		ParameterControl:       o.ParameterControl,
+		Initialization:         initialization,
+		MaxGenerations:         s.MaxIterations,
+		MaxEvaluations:         s.MaxEvaluations,
+		TargetFitness:          s.TargetFitness,
+		StallPeriod:            s.StallPeriod,
+		StallFactor:            s.StallFactor,
+		FitnessFunction:        p.FitnessFunction,
+		FitnessFunctionFactory: p.FitnessFunctionFactory,
+		Workers:                s.Workers,
+		Observers:              observers,
+		Rand:                   s.Rand,
+	})
+	r, err := evolver.Run(ctx)
+	return Result{
+		BestAgent:   r.BestAgent,
+		BestFitness: r.BestFitness,
+		Iterations:  r.Generations,
+		Evaluations: r.Evaluations,
+		StopReason:  r.StopReason,
+	}, err
+}
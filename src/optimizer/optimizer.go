@@ -0,0 +1,253 @@
+package optimizer
+
+import (
+	"arrays"
+	"context"
+	de "differentialEvolution"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+	"utils"
+)
+
+// Problem is what an optimizer minimizes
+// If `FitnessFunctionFactory` is nil, `FitnessFunction` is shared by all workers
+// and must be safe for concurrent use
+type Problem struct {
+	SearchSpace            []utils.Range1D
+	FitnessFunction        de.FitnessFunction
+	FitnessFunctionFactory de.FitnessFunctionFactory
+}
+
+// Optimizer minimizes the fitness function of a problem within its search space
+type Optimizer interface {
+	// Optimize runs until the optimizer's budget is used or `ctx` ends, returning the best agent found
+	Optimize(ctx context.Context, p Problem) (Result, error)
+}
+
+// Result is the outcome of `Optimizer.Optimize`
+type Result struct {
+	BestAgent   *arrays.Array1D
+	BestFitness float64
+	Iterations  int
+	Evaluations int
+	StopReason  de.StopReason
+}
+
+// Progress is reported to `Settings.OnIteration` after each iteration
+type Progress struct {
+	Iteration   int
+	Evaluations int
+	BestAgent   *arrays.Array1D
+	BestFitness float64
+}
+
+// Settings common to every optimizer
+type Settings struct {
+	PopulationSize int // defaults to a value suited to each algorithm
+	// the optimizer stops on any of these that is set
+	MaxIterations  int
+	MaxEvaluations int
+	TargetFitness  float64 // only checked if greater than or equal to 0
+	StallPeriod    int     // in iterations
+	StallFactor    float64
+	// agents the search starts from, such as the current joint configuration
+	InitialAgents []*arrays.Array1D
+	Workers       int        // defaults to the number of CPUs
+	Rand          *rand.Rand // defaults to a source seeded with the current time
+	OnIteration   func(p Progress)
+}
+
+type Algorithm string
+
+const (
+	DEAlgorithm    Algorithm = "de"
+	CMAESAlgorithm Algorithm = "cmaes"
+	PSOAlgorithm   Algorithm = "pso"
+)
+
+var Algorithms = []Algorithm{DEAlgorithm, CMAESAlgorithm, PSOAlgorithm}
+
+// New builds the optimizer for `algorithm` with its default parameters
+func New(algorithm Algorithm, s Settings) (Optimizer, error) {
+	switch algorithm {
+	case DEAlgorithm:
+		return &DifferentialEvolution{Settings: s, CrossoverRate: 0.5, WeightingFactor: 0.5}, nil
+	case CMAESAlgorithm:
+		return &CMAES{Settings: s}, nil
+	case PSOAlgorithm:
+		return &ParticleSwarm{Settings: s}, nil
+	}
+	return nil, fmt.Errorf("unknown algorithm %q, expected one of %v", algorithm, Algorithms)
+}
+
+func (s *Settings) validate(p Problem) error {
+	if len(p.SearchSpace) == 0 {
+		return fmt.Errorf("empty search space")
+	}
+	for j, space := range p.SearchSpace {
+		if err := space.Validate(); err != nil {
+			return fmt.Errorf("feature %d: %v", j, err)
+		}
+	}
+	if p.FitnessFunction == nil && p.FitnessFunctionFactory == nil {
+		return fmt.Errorf("no fitness function")
+	}
+	if s.MaxIterations <= 0 && s.MaxEvaluations <= 0 {
+		return fmt.Errorf("max iterations or max evaluations must be set")
+	}
+	for _, agent := range s.InitialAgents {
+		if agent.Length() != len(p.SearchSpace) {
+			return fmt.Errorf("initial agent of size %d, expected %d", agent.Length(), len(p.SearchSpace))
+		}
+	}
+	return nil
+}
+
+func (s *Settings) applyDefaults(populationSize int) {
+	if s.PopulationSize <= 0 {
+		s.PopulationSize = populationSize
+	}
+	if s.Workers <= 0 {
+		s.Workers = runtime.NumCPU()
+	}
+	if s.Rand == nil {
+		s.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+}
+
+// keeps track of the best agent and of the budget of the optimizers implemented in this package
+type tracker struct {
+	settings    Settings
+	iteration   int
+	evaluations int
+	bestAgent   *arrays.Array1D
+	bestFitness float64
+	history     []float64 // best fitness after each iteration
+}
+
+func newTracker(s Settings) *tracker {
+	return &tracker{settings: s, bestFitness: math.Inf(1)}
+}
+
+// NaN fitness is taken as +Inf, and the first agent observed is the best until a fitter one is,
+// so there is a best agent even if no fitness is finite
+func (t *tracker) observe(agent *arrays.Array1D, fitness float64) {
+	t.evaluations++
+	if math.IsNaN(fitness) {
+		fitness = math.Inf(1)
+	}
+	if t.bestAgent == nil || fitness < t.bestFitness {
+		t.bestFitness = fitness
+		t.bestAgent = agent.Copy()
+	}
+}
+
+func (t *tracker) endIteration() {
+	t.iteration++
+	t.history = append(t.history, t.bestFitness)
+	if t.settings.OnIteration != nil {
+		t.settings.OnIteration(Progress{
+			Iteration:   t.iteration,
+			Evaluations: t.evaluations,
+			BestAgent:   t.bestAgent.Copy(),
+			BestFitness: t.bestFitness,
+		})
+	}
+}
+
+func (t *tracker) stopReason(ctx context.Context) de.StopReason {
+	switch ctx.Err() {
+	case context.Canceled:
+		return de.Cancelled
+	case context.DeadlineExceeded:
+		return de.DeadlineExceeded
+	}
+	s := t.settings
+	if s.MaxIterations > 0 && t.iteration >= s.MaxIterations {
+		return de.MaxGenerationsReached
+	}
+	if s.MaxEvaluations > 0 && t.evaluations >= s.MaxEvaluations {
+		return de.MaxEvaluationsReached
+	}
+	if s.TargetFitness >= 0 && t.bestFitness <= s.TargetFitness {
+		return de.TargetFitnessReached
+	}
+	if s.StallPeriod > 0 && t.stalled() {
+		return de.FitnessStalled
+	}
+	return de.NotStopped
+}
+
+// same rule as `de.FitnessStall`
+func (t *tracker) stalled() bool {
+	history := t.history
+	if len(history) <= t.settings.StallPeriod {
+		return false
+	}
+	for i := len(history) - t.settings.StallPeriod; i < len(history); i++ {
+		improvementRatio := (history[i-1] - history[i]) / history[i-1]
+		if math.IsNaN(improvementRatio) || improvementRatio > t.settings.StallFactor {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *tracker) result(reason de.StopReason) Result {
+	return Result{
+		BestAgent:   t.bestAgent,
+		BestFitness: t.bestFitness,
+		Iterations:  t.iteration,
+		Evaluations: t.evaluations,
+		StopReason:  reason,
+	}
+}
+
+// evaluates agents on several goroutines, each with its own fitness function
+type evaluator struct {
+	fitnessFunctions []de.FitnessFunction
+}
+
+func newEvaluator(p Problem, workers int) evaluator {
+	functions := make([]de.FitnessFunction, workers)
+	for i := range functions {
+		if p.FitnessFunctionFactory != nil {
+			functions[i] = p.FitnessFunctionFactory()
+		} else {
+			functions[i] = p.FitnessFunction
+		}
+	}
+	return evaluator{functions}
+}
+
+func (ev evaluator) evaluate(agents []*arrays.Array1D) []float64 {
+	fitness := make([]float64, len(agents))
+	var wg sync.WaitGroup
+	for w, function := range ev.fitnessFunctions {
+		wg.Add(1)
+		go func(w int, function de.FitnessFunction) {
+			defer wg.Done()
+			for i := w; i < len(agents); i += len(ev.fitnessFunctions) {
+				fitness[i] = function(agents[i])
+			}
+		}(w, function)
+	}
+	wg.Wait()
+	return fitness
+}
+
+// maps a value in [0, 1] to the range, and back
+func fromUnit(unit float64, r utils.Range1D) float64 {
+	return r.LowerBound + unit*(r.UpperBound-r.LowerBound)
+}
+
+func toUnit(value float64, r utils.Range1D) float64 {
+	if r.UpperBound == r.LowerBound {
+		return 0
+	}
+	return (value - r.LowerBound) / (r.UpperBound - r.LowerBound)
+}
@@ -0,0 +1,85 @@
+package optimizer
+
+import (
+	"arrays"
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"utils"
+)
+
+func sphere(agent *arrays.Array1D) float64 {
+	sum := 0.0
+	for _, x := range *agent {
+		sum += (x - 1) * (x - 1)
+	}
+	return sum
+}
+
+func sphereProblem() Problem {
+	return Problem{
+		SearchSpace:     []utils.Range1D{{LowerBound: -5, UpperBound: 5}, {LowerBound: -5, UpperBound: 5}, {LowerBound: -5, UpperBound: 5}},
+		FitnessFunction: sphere,
+	}
+}
+
+func TestSphereConvergence(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		o, err := New(algorithm, Settings{PopulationSize: 20, MaxEvaluations: 20000, Workers: 2, Rand: rand.New(rand.NewSource(1))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := o.Optimize(context.Background(), sphereProblem())
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if result.BestFitness > 1e-6 {
+			t.Errorf("%s ends at %v with fitness %v, expected the minimum at (1, 1, 1)", algorithm, result.BestAgent, result.BestFitness)
+		}
+		if result.Evaluations > 20000+100 {
+			t.Errorf("%s used %d evaluations, over its budget", algorithm, result.Evaluations)
+		}
+	}
+}
+
+func TestParticleSwarmWithoutFiniteFitness(t *testing.T) {
+	p := sphereProblem()
+	p.FitnessFunction = func(*arrays.Array1D) float64 { return math.NaN() }
+	o := &ParticleSwarm{Settings: Settings{MaxIterations: 3, Rand: rand.New(rand.NewSource(1))}}
+	result, err := o.Optimize(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BestAgent == nil {
+		t.Errorf("no best agent")
+	}
+}
+
+func TestCMAESRejectsTooSmallPopulations(t *testing.T) {
+	o := &CMAES{Settings: Settings{PopulationSize: 1, MaxIterations: 3, Rand: rand.New(rand.NewSource(1))}}
+	if _, err := o.Optimize(context.Background(), sphereProblem()); err == nil {
+		t.Errorf("expected an error for a population of 1")
+	}
+}
+
+func TestDifferentialEvolutionParameters(t *testing.T) {
+	settings := Settings{MaxIterations: 3, Rand: rand.New(rand.NewSource(1))}
+	cases := []struct {
+		crossoverRate, weightingFactor float64
+		valid                          bool
+	}{
+		{0, 0.5, true},
+		{1, 2, true},
+		{-0.1, 0.5, false},
+		{1.1, 0.5, false},
+		{0.5, 0, false},
+	}
+	for _, c := range cases {
+		o := &DifferentialEvolution{Settings: settings, CrossoverRate: c.crossoverRate, WeightingFactor: c.weightingFactor}
+		_, err := o.Optimize(context.Background(), sphereProblem())
+		if (err == nil) != c.valid {
+			t.Errorf("crossover rate %v and weighting factor %v: got error %v, expected valid %v", c.crossoverRate, c.weightingFactor, err, c.valid)
+		}
+	}
+}
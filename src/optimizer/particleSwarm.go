@@ -0,0 +1,97 @@
+package optimizer
+
+import (
+	"arrays"
+	"context"
+	de "differentialEvolution"
+	"math"
+	"utils"
+)
+
+// ParticleSwarm is global-best particle swarm optimization with an inertia weight,
+// with `PopulationSize` particles
+// Particles leaving the search space are brought back following each range's policy,
+// with their previous position as parent
+type ParticleSwarm struct {
+	Settings
+	Inertia   float64 // defaults to 0.7298
+	Cognitive float64 // attraction to the particle's own best position, defaults to 1.49618
+	Social    float64 // attraction to the swarm's best position, defaults to 1.49618
+	// velocities are limited to `MaxVelocity` times the width of each feature's range, defaults to 0.2
+	MaxVelocity float64
+}
+
+const DefaultSwarmSize = 40
+
+func (o *ParticleSwarm) Optimize(ctx context.Context, p Problem) (Result, error) {
+	if err := o.validate(p); err != nil {
+		return Result{}, err
+	}
+	s := o.Settings
+	s.applyDefaults(DefaultSwarmSize)
+	inertia, cognitive, social, maxVelocity := o.Inertia, o.Cognitive, o.Social, o.MaxVelocity
+	if inertia <= 0 {
+		inertia = 0.7298
+	}
+	if cognitive <= 0 {
+		cognitive = 1.49618
+	}
+	if social <= 0 {
+		social = 1.49618
+	}
+	if maxVelocity <= 0 {
+		maxVelocity = 0.2
+	}
+	n := len(p.SearchSpace)
+
+	positions := make([]*arrays.Array1D, s.PopulationSize)
+	velocities := make([]arrays.Array1D, s.PopulationSize)
+	for i := range positions {
+		position := make(arrays.Array1D, n)
+		velocities[i] = make(arrays.Array1D, n)
+		for j, space := range p.SearchSpace {
+			if i < len(s.InitialAgents) {
+				position[j] = utils.ConstrainValue(s.InitialAgents[i].Get(j), space)
+			} else {
+				position[j] = utils.RandomInRange(s.Rand, space)
+			}
+			limit := maxVelocity * (space.UpperBound - space.LowerBound)
+			velocities[i][j] = utils.RandomInRange(s.Rand, utils.Range1D{LowerBound: -limit, UpperBound: limit})
+		}
+		positions[i] = &position
+	}
+
+	t := newTracker(s)
+	ev := newEvaluator(p, s.Workers)
+	bestPositions := make([]*arrays.Array1D, s.PopulationSize)
+	bestFitness := make([]float64, s.PopulationSize)
+	for i, fitness := range ev.evaluate(positions) {
+		t.observe(positions[i], fitness)
+		bestPositions[i], bestFitness[i] = positions[i].Copy(), fitness
+	}
+	for {
+		if reason := t.stopReason(ctx); reason != de.NotStopped {
+			return t.result(reason), nil
+		}
+		for i, position := range positions {
+			for j, space := range p.SearchSpace {
+				x := position.Get(j)
+				limit := maxVelocity * (space.UpperBound - space.LowerBound)
+				v := inertia*velocities[i][j] +
+					cognitive*s.Rand.Float64()*(bestPositions[i].Get(j)-x) +
+					social*s.Rand.Float64()*(t.bestAgent.Get(j)-x)
+				v = math.Max(-limit, math.Min(v, limit))
+				newX := utils.HandleBounds(s.Rand, x+v, x, space)
+				velocities[i][j] = newX - x
+				position.Set(j, newX)
+			}
+		}
+		for i, fitness := range ev.evaluate(positions) {
+			t.observe(positions[i], fitness)
+			if fitness < bestFitness[i] {
+				bestPositions[i], bestFitness[i] = positions[i].Copy(), fitness
+			}
+		}
+		t.endIteration()
+	}
+}
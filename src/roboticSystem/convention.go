@@ -0,0 +1,125 @@
+package roboticSystem
+
+import (
+	"arrays"
+	"math"
+)
+
+// DHConvention tells how the DH parameters of a link are turned into its transformation
+type DHConvention int
+
+const (
+	// StandardDH (distal): frame i sits at the end of link i, and link i holds θi, di, ai and αi
+	// T = Rz(θ)·Tz(d)·Tx(r)·Rx(α)
+	StandardDH DHConvention = iota
+	// ModifiedDH (proximal, Craig): frame i sits at joint i, and link i holds θi and di along with
+	// a(i-1) and α(i-1) of the previous link, as in most vendor datasheets
+	// T = Rx(α)·Tx(r)·Rz(θ)·Tz(d)
+	ModifiedDH
+)
+
+func (c DHConvention) String() string {
+	switch c {
+	case StandardDH:
+		return "standard"
+	case ModifiedDH:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// https://en.wikipedia.org/wiki/Denavit%E2%80%93Hartenberg_parameters#Modified_DH_parameters
+func (dh DHParameters) ModifiedTransformationMatrix() *arrays.Array2D {
+	cosTheta := math.Cos(dh.Theta)
+	sinTheta := math.Sin(dh.Theta)
+	cosAlpha := math.Cos(dh.Alpha)
+	sinAlpha := math.Sin(dh.Alpha)
+	return &arrays.Array2D{
+		{cosTheta, -sinTheta, 0, dh.R},
+		{sinTheta * cosAlpha, cosTheta * cosAlpha, -sinAlpha, -dh.D * sinAlpha},
+		{sinTheta * sinAlpha, cosTheta * sinAlpha, cosAlpha, dh.D * cosAlpha},
+		{0, 0, 0, 1},
+	}
+}
+
+func (dh DHParameters) ConventionTransformationMatrix(convention DHConvention) *arrays.Array2D {
+	if convention == ModifiedDH {
+		return dh.ModifiedTransformationMatrix()
+	}
+	return dh.TransformationMatrix()
+}
+
+func ParametersToConventionTransformationMatrix(convention DHConvention, dhParams []DHParameters) *arrays.Array2D {
+	baseMatrix := arrays.Identity2D(4)
+	for i := 0; i < len(dhParams); i++ {
+		baseMatrix = baseMatrix.Multiply(dhParams[i].ConventionTransformationMatrix(convention))
+	}
+	return baseMatrix
+}
+
+// ConvertLinks rewrites `links`, given in convention `from`, in convention `to`, keeping the
+// transformation of the whole chain
+// As Tx(r) and Rx(α) commute, the chains only differ in which link holds r and α: converting to
+// modified moves them to the next link, converting to standard moves them to the previous one
+// The r and α that do not fit in the chain, if not null, go into an extra fixed link, at the end
+// when converting to modified and at the start when converting to standard, and links left with
+// null parameters and no variables are dropped, so converting back and forth does not grow the chain
+// Variables on θ and d stay on their link, those on r and α move along with their parameter,
+// and come before θ and d in modified links, after them in standard ones, which keeps the agent
+// layout of links that list their variables in that order
+func ConvertLinks(links []Link, from, to DHConvention) []Link {
+	if from == to {
+		return append([]Link(nil), links...)
+	}
+	n := len(links)
+	// converted[i] takes θ and d from links[i], r and α from links[i+shift]
+	shift := -1
+	if to == StandardDH {
+		shift = 1
+	}
+	// an extra fixed link at index -1 or n, holding θ = d = 0
+	converted := make([]Link, 0, n+1)
+	for i := -1; i <= n; i++ {
+		var link Link
+		hasJoint := i >= 0 && i < n
+		if hasJoint {
+			link.DHParameters.Theta = links[i].DHParameters.Theta
+			link.DHParameters.D = links[i].DHParameters.D
+			link.Variables = jointVariables(links[i].variables(), true)
+		}
+		if j := i + shift; j >= 0 && j < n {
+			link.DHParameters.R = links[j].DHParameters.R
+			link.DHParameters.Alpha = links[j].DHParameters.Alpha
+			if to == ModifiedDH {
+				link.Variables = append(jointVariables(links[j].variables(), false), link.Variables...)
+			} else {
+				link.Variables = append(link.Variables, jointVariables(links[j].variables(), false)...)
+			}
+		}
+		if link.DHParameters == (DHParameters{}) && len(link.Variables) == 0 {
+			// an identity transformation, e.g. the extra link of a previous conversion
+			continue
+		}
+		converted = append(converted, link)
+	}
+	return converted
+}
+
+// variables on θ and d if `joint`, otherwise variables on r and α
+func jointVariables(variables []Variable, joint bool) []Variable {
+	var selected []Variable
+	for _, variable := range variables {
+		if (variable.Field == ThetaField || variable.Field == DField) == joint {
+			selected = append(selected, variable)
+		}
+	}
+	return selected
+}
+
+// WithConvention returns a copy of `s` with its links rewritten in `convention`
+func (s *System) WithConvention(convention DHConvention) System {
+	converted := s.Copy()
+	converted.Links = ConvertLinks(s.Links, s.Convention, convention)
+	converted.Convention = convention
+	return converted
+}
@@ -0,0 +1,61 @@
+package roboticSystem
+
+import (
+	"arrays"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"utils"
+)
+
+func TestConvertLinksRoundTrip(t *testing.T) {
+	angles := utils.Range1D{LowerBound: -math.Pi, UpperBound: math.Pi}
+	lengths := utils.Range1D{LowerBound: 0, UpperBound: 0.5}
+	system := NewSystem(0, 0, 0)
+	system.AddLink(DHParameters{D: 0.3, Alpha: math.Pi / 2}, angles)
+	system.AddPrismaticLink(DHParameters{Theta: 0.2, R: 0.1, Alpha: -math.Pi / 3}, lengths)
+	system.AddLinkWithVariables(DHParameters{D: 0.05}, Variable{ThetaField, angles}, Variable{RField, lengths}, Variable{AlphaField, angles})
+	system.AddLinkWithVariables(DHParameters{R: 0.2, Alpha: 0.4})
+
+	modified := system.WithConvention(ModifiedDH)
+	roundTrip := modified.WithConvention(StandardDH)
+	if roundTrip.Length() != system.Length() {
+		t.Fatalf("round trip gives %d links, expected %d", roundTrip.Length(), system.Length())
+	}
+	for i := range system.Links {
+		original, converted := system.Links[i], roundTrip.Links[i]
+		if original.DHParameters != converted.DHParameters || !reflect.DeepEqual(original.variables(), converted.variables()) {
+			t.Errorf("link %d is %+v after the round trip, expected %+v", i, converted, original)
+		}
+	}
+	if !reflect.DeepEqual(modified.VariableSpace(), system.VariableSpace()) {
+		t.Errorf("modified links have variables %v, expected %v", modified.VariableSpace(), system.VariableSpace())
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for k := 0; k < 20; k++ {
+		agent := make(arrays.Array1D, system.VariableCount())
+		for j, space := range system.VariableSpace() {
+			agent[j] = utils.RandomInRange(rng, space)
+		}
+		expected := system.Copy()
+		expected.UpdateVariables(&agent)
+		for _, s := range []System{modified.Copy(), roundTrip.Copy()} {
+			s.UpdateVariables(&agent)
+			if d := maxDifference(s.Transformation(), expected.Transformation()); d > 1e-12 {
+				t.Errorf("%s links differ by %g from standard ones at %v", s.Convention, d, agent)
+			}
+		}
+	}
+}
+
+func maxDifference(a, b *arrays.Array2D) float64 {
+	difference := 0.0
+	for i := 0; i < a.NRows(); i++ {
+		for j := 0; j < a.NColumns(); j++ {
+			difference = math.Max(difference, math.Abs(a.GetValue(i, j)-b.GetValue(i, j)))
+		}
+	}
+	return difference
+}
@@ -0,0 +1,297 @@
+package roboticSystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"utils"
+	"vectors"
+)
+
+// Definition describes a robot declaratively, in JSON or YAML, e.g.
+//
+//	{
+//	  "convention": "standard",
+//	  "angles": "degrees",
+//	  "base": {"position": [0, 0, 0]},
+//	  "links": [
+//	    {"joint": "revolute", "d": 0.03, "alpha": 90, "limits": [0, 180]},
+//	    {"joint": "prismatic", "r": 0.1, "limits": [0, 0.2]},
+//	    {"joint": "fixed", "r": 0.05}
+//	  ],
+//	  "tool": {"position": [0, 0, 0.02], "rpy": [0, 90, 0]}
+//	}
+//
+// Lengths are in meters, and every angle, limits of angles included, is in the unit of `Angles`
+type Definition struct {
+	Name       string `json:"name"`
+	Convention string `json:"convention"` // "standard" (default) or "modified", see `DHConvention`
+	Angles     string `json:"angles"`     // "radians" (default) or "degrees"
+	// base pose, defaults to the origin with no rotation
+	Base  *PoseDefinition  `json:"base"`
+	Links []LinkDefinition `json:"links"`
+	// pose of the tool tip in the frame of the last link, defaults to none
+	Tool *PoseDefinition `json:"tool"`
+}
+
+type PoseDefinition struct {
+	Position []float64 `json:"position"` // x, y, z, defaults to 0, 0, 0
+	RPY      []float64 `json:"rpy"`      // roll, pitch, yaw, see `RotationFromRPY`, defaults to 0, 0, 0
+}
+
+type LinkDefinition struct {
+	// "revolute" (theta varies), "prismatic" (d varies) or "fixed"
+	Joint string  `json:"joint"`
+	Theta float64 `json:"theta"`
+	D     float64 `json:"d"`
+	R     float64 `json:"r"`
+	Alpha float64 `json:"alpha"`
+	// lower and upper bounds of the joint variable, required unless the joint is fixed
+	Limits []float64 `json:"limits"`
+	// other DH parameters of the link that are optimized
+	Variables []VariableDefinition `json:"variables"`
+}
+
+type VariableDefinition struct {
+	Parameter string    `json:"parameter"` // "theta", "d", "r" or "alpha"
+	Limits    []float64 `json:"limits"`
+}
+
+// DefinitionError points at the field of a robot definition that is invalid, e.g. `links[2].limits`
+type DefinitionError struct {
+	Field   string
+	Message string
+}
+
+func (e DefinitionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// LoadSystem reads a robot definition from a JSON file, or a YAML file if its extension is
+// .yaml or .yml
+func LoadSystem(path string) (System, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return System{}, err
+	}
+	parse := ParseSystem
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parse = ParseYAMLSystem
+	}
+	system, err := parse(data)
+	if err != nil {
+		return System{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return system, nil
+}
+
+// ParseSystem builds a system from a JSON robot definition, see `Definition`
+// Unknown fields are errors, so misspelt fields do not go unnoticed
+func ParseSystem(data []byte) (System, error) {
+	definition, err := decodeDefinition(data)
+	if err != nil {
+		return System{}, describeJSONError(data, err)
+	}
+	return definition.System()
+}
+
+// ParseYAMLSystem builds a system from a robot definition in YAML, with the same fields as in JSON
+// Only the subset of YAML robot definitions need is supported: block mappings and sequences
+// indented with spaces, flow sequences and mappings on a single line, plain and quoted scalars,
+// and comments, but no anchors, aliases, tags, block scalars or multiple documents
+func ParseYAMLSystem(data []byte) (System, error) {
+	document, err := parseYAML(data)
+	if err != nil {
+		return System{}, err
+	}
+	// the document goes through JSON so that it is decoded as strictly as JSON definitions
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return System{}, err
+	}
+	definition, err := decodeDefinition(encoded)
+	if err != nil {
+		// offsets in the JSON do not match the YAML document, only the field is reported
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &typeError) && typeError.Field != "" {
+			return System{}, typeDefinitionError(typeError)
+		}
+		return System{}, err
+	}
+	return definition.System()
+}
+
+func decodeDefinition(data []byte) (Definition, error) {
+	var definition Definition
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&definition)
+	return definition, err
+}
+
+// System validates the definition and builds the system it describes
+// All invalid fields are reported, each as a `DefinitionError`
+func (d Definition) System() (System, error) {
+	var errs []error
+	invalid := func(field, format string, args ...interface{}) {
+		errs = append(errs, DefinitionError{field, fmt.Sprintf(format, args...)})
+	}
+
+	var system System
+	switch d.Convention {
+	case "", "standard":
+		system.Convention = StandardDH
+	case "modified":
+		system.Convention = ModifiedDH
+	default:
+		invalid("convention", "unknown convention %q, expected standard or modified", d.Convention)
+	}
+	angle := func(value float64) float64 { return value }
+	switch d.Angles {
+	case "", "radians":
+	case "degrees":
+		angle = func(value float64) float64 { return value * math.Pi / 180 }
+	default:
+		invalid("angles", "unknown unit %q, expected radians or degrees", d.Angles)
+	}
+
+	pose := func(field string, p *PoseDefinition) Pose {
+		position, rpy := []float64{0, 0, 0}, []float64{0, 0, 0}
+		if p != nil && p.Position != nil {
+			if len(p.Position) != 3 {
+				invalid(field+".position", "expected 3 values (x, y, z), got %d", len(p.Position))
+			} else {
+				position = p.Position
+			}
+		}
+		if p != nil && p.RPY != nil {
+			if len(p.RPY) != 3 {
+				invalid(field+".rpy", "expected 3 values (roll, pitch, yaw), got %d", len(p.RPY))
+			} else {
+				rpy = p.RPY
+			}
+		}
+		return PoseFromRPY(vectors.NewVector3D(position[0], position[1], position[2]),
+			angle(rpy[0]), angle(rpy[1]), angle(rpy[2]))
+	}
+	// limits of a variable on `parameter`, converted to radians for angles
+	limits := func(field string, values []float64, parameter DHField) utils.Range1D {
+		if values == nil {
+			invalid(field, "required")
+			return utils.Range1D{}
+		}
+		if len(values) != 2 {
+			invalid(field, "expected 2 values (lower, upper), got %d", len(values))
+			return utils.Range1D{}
+		}
+		if values[0] > values[1] {
+			invalid(field, "lower bound %v greater than upper bound %v", values[0], values[1])
+		}
+		if parameter == ThetaField || parameter == AlphaField {
+			return utils.Range1D{LowerBound: angle(values[0]), UpperBound: angle(values[1])}
+		}
+		return utils.Range1D{LowerBound: values[0], UpperBound: values[1]}
+	}
+
+	base := pose("base", d.Base)
+	system.BasePosition = base.Position
+	if d.Base != nil && d.Base.RPY != nil {
+		system.BaseOrientation = base.Orientation
+	}
+	if d.Tool != nil {
+		system.Tool = pose("tool", d.Tool).TransformationMatrix()
+	}
+	if len(d.Links) == 0 {
+		invalid("links", "at least one link is required")
+	}
+	for i, l := range d.Links {
+		field := fmt.Sprintf("links[%d]", i)
+		dh := DHParameters{Theta: angle(l.Theta), D: l.D, R: l.R, Alpha: angle(l.Alpha)}
+		var variables []Variable
+		switch l.Joint {
+		case "revolute":
+			variables = append(variables, Variable{ThetaField, limits(field+".limits", l.Limits, ThetaField)})
+		case "prismatic":
+			variables = append(variables, Variable{DField, limits(field+".limits", l.Limits, DField)})
+		case "fixed":
+			if l.Limits != nil {
+				invalid(field+".limits", "fixed joints have no limits")
+			}
+		case "":
+			invalid(field+".joint", "required, expected revolute, prismatic or fixed")
+		default:
+			invalid(field+".joint", "unknown joint type %q, expected revolute, prismatic or fixed", l.Joint)
+		}
+		for j, v := range l.Variables {
+			variableField := fmt.Sprintf("%s.variables[%d]", field, j)
+			parameter, ok := map[string]DHField{"theta": ThetaField, "d": DField, "r": RField, "alpha": AlphaField}[v.Parameter]
+			if !ok {
+				invalid(variableField+".parameter", "unknown parameter %q, expected theta, d, r or alpha", v.Parameter)
+				continue
+			}
+			for _, other := range variables {
+				if other.Field == parameter {
+					invalid(variableField+".parameter", "%s is already a variable of the link", parameter)
+				}
+			}
+			variables = append(variables, Variable{parameter, limits(variableField+".limits", v.Limits, parameter)})
+		}
+		system.AddLinkWithVariables(dh, variables...)
+	}
+	if len(errs) > 0 {
+		return System{}, errors.Join(errs...)
+	}
+	return system, nil
+}
+
+// adds the line and column of syntax and type errors, which the decoder only gives as an offset
+func describeJSONError(data []byte, err error) error {
+	var offset int64
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxError):
+		offset = syntaxError.Offset
+	case errors.As(err, &typeError):
+		offset = typeError.Offset
+		if typeError.Field != "" {
+			err = typeDefinitionError(typeError)
+		}
+	default:
+		return err
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+func typeDefinitionError(err *json.UnmarshalTypeError) DefinitionError {
+	return DefinitionError{jsonFieldPath(err.Field), fmt.Sprintf("expected %s, got %s", err.Type, err.Value)}
+}
+
+// rewrites the path of a field as given by the decoder, e.g. `links.2.limits`, as `links[2].limits`
+func jsonFieldPath(path string) string {
+	var field strings.Builder
+	for i, part := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			fmt.Fprintf(&field, "[%s]", part)
+			continue
+		}
+		if i > 0 {
+			field.WriteString(".")
+		}
+		field.WriteString(part)
+	}
+	return field.String()
+}
@@ -0,0 +1,79 @@
+package roboticSystem
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDefinitionErrors(t *testing.T) {
+	const link = `{"joint": "revolute", "limits": [0, 1]}`
+	cases := []struct {
+		definition string
+		field      string
+	}{
+		{`{"convention": "craig", "links": [` + link + `]}`, "convention"},
+		{`{"angles": "gradians", "links": [` + link + `]}`, "angles"},
+		{`{"base": {"position": [0, 0]}, "links": [` + link + `]}`, "base.position"},
+		{`{"base": {"rpy": [0]}, "links": [` + link + `]}`, "base.rpy"},
+		{`{"tool": {"position": [0, 0, 0, 0]}, "links": [` + link + `]}`, "tool.position"},
+		{`{"tool": {"rpy": []}, "links": [` + link + `]}`, "tool.rpy"},
+		{`{"links": []}`, "links"},
+		{`{"links": [{"limits": [0, 1]}]}`, "links[0].joint"},
+		{`{"links": [` + link + `, {"joint": "spherical", "limits": [0, 1]}]}`, "links[1].joint"},
+		{`{"links": [{"joint": "revolute"}]}`, "links[0].limits"},
+		{`{"links": [{"joint": "prismatic", "limits": [0]}]}`, "links[0].limits"},
+		{`{"links": [{"joint": "revolute", "limits": [1, 0]}]}`, "links[0].limits"},
+		{`{"links": [{"joint": "fixed", "limits": [0, 1]}]}`, "links[0].limits"},
+		{`{"links": [{"joint": "fixed", "variables": [{"parameter": "beta", "limits": [0, 1]}]}]}`, "links[0].variables[0].parameter"},
+		{`{"links": [{"joint": "revolute", "limits": [0, 1], "variables": [{"parameter": "theta", "limits": [0, 1]}]}]}`, "links[0].variables[0].parameter"},
+		{`{"links": [{"joint": "fixed", "variables": [{"parameter": "r", "limits": [2, 1]}]}]}`, "links[0].variables[0].limits"},
+		{`{"links": [{"joint": "revolute", "limits": "wide"}]}`, "links[0].limits"},
+	}
+	for _, c := range cases {
+		_, err := ParseSystem([]byte(c.definition))
+		if fields := definitionErrorFields(err); !reflect.DeepEqual(fields, []string{c.field}) {
+			t.Errorf("%s: got errors on %v (%v), expected one on %s", c.definition, fields, err, c.field)
+		}
+	}
+}
+
+func TestYAMLDefinition(t *testing.T) {
+	fromJSON, err := LoadSystem("../../robots/four_links.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := LoadSystem("../../robots/four_links.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("YAML definition gives %+v, expected %+v as from JSON", fromYAML, fromJSON)
+	}
+
+	_, err = ParseYAMLSystem([]byte("links:\n  - joint: revolute\n    limits: [1, 0]\n"))
+	if fields := definitionErrorFields(err); !reflect.DeepEqual(fields, []string{"links[0].limits"}) {
+		t.Errorf("got errors on %v (%v), expected one on links[0].limits", fields, err)
+	}
+	if _, err = ParseYAMLSystem([]byte("links:\n  - joint: revolute\n   limits: [0, 1]\n")); err == nil {
+		t.Errorf("expected an error for inconsistent indentation")
+	}
+}
+
+// fields of the `DefinitionError`s in `err`
+func definitionErrorFields(err error) []string {
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else if err != nil {
+		errs = []error{err}
+	}
+	var fields []string
+	for _, err := range errs {
+		var definitionError DefinitionError
+		if errors.As(err, &definitionError) {
+			fields = append(fields, definitionError.Field)
+		}
+	}
+	return fields
+}
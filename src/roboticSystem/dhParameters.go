@@ -13,6 +13,55 @@ type DHParameters struct {
 	Alpha float64
 }
 
+// DHField names one of the four DH parameters
+type DHField int
+
+const (
+	ThetaField DHField = iota
+	DField
+	RField
+	AlphaField
+)
+
+func (f DHField) String() string {
+	switch f {
+	case ThetaField:
+		return "theta"
+	case DField:
+		return "d"
+	case RField:
+		return "r"
+	case AlphaField:
+		return "alpha"
+	}
+	return "unknown"
+}
+
+func (dh DHParameters) Get(field DHField) float64 {
+	switch field {
+	case DField:
+		return dh.D
+	case RField:
+		return dh.R
+	case AlphaField:
+		return dh.Alpha
+	}
+	return dh.Theta
+}
+
+func (dh *DHParameters) Set(field DHField, value float64) {
+	switch field {
+	case DField:
+		dh.D = value
+	case RField:
+		dh.R = value
+	case AlphaField:
+		dh.Alpha = value
+	default:
+		dh.Theta = value
+	}
+}
+
 func (dh DHParameters) TransformationMatrix() *arrays.Array2D {
 	cosTheta := math.Cos(dh.Theta)
 	sinTheta := math.Sin(dh.Theta)
@@ -0,0 +1,158 @@
+package roboticSystem
+
+import (
+	"arrays"
+	"fmt"
+	"math"
+	"vectors"
+)
+
+// tolerance for a matrix to be taken as a rotation
+const rotationTolerance = 1e-6
+
+// Pose is a position along with an orientation, given as a 3x3 rotation matrix
+type Pose struct {
+	Position    vectors.Vector3D
+	Orientation *arrays.Array2D
+}
+
+func (p Pose) String() string {
+	roll, pitch, yaw := RPYFromRotation(p.Orientation)
+	return fmt.Sprintf("%s rpy(%.5f,%.5f,%.5f)", p.Position, roll, pitch, yaw)
+}
+
+// NewPose fails if `rotation` is not a proper rotation matrix (orthonormal, with determinant 1)
+func NewPose(position vectors.Vector3D, rotation *arrays.Array2D) (Pose, error) {
+	if rotation.NRows() != 3 || rotation.NColumns() != 3 {
+		return Pose{}, fmt.Errorf("rotation matrix must be 3x3, got %dx%d", rotation.NRows(), rotation.NColumns())
+	}
+	product := transpose3(rotation).Multiply(rotation)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			expected := 0.0
+			if i == j {
+				expected = 1
+			}
+			if math.Abs(product.GetValue(i, j)-expected) > rotationTolerance {
+				return Pose{}, fmt.Errorf("rotation matrix is not orthonormal")
+			}
+		}
+	}
+	if math.Abs(determinant3(rotation)-1) > rotationTolerance {
+		return Pose{}, fmt.Errorf("rotation matrix has determinant %.5f, expected 1", determinant3(rotation))
+	}
+	return Pose{Position: position, Orientation: rotation}, nil
+}
+
+// PoseFromQuaternion takes the orientation as the quaternion w + xi + yj + zk, which is normalized
+func PoseFromQuaternion(position vectors.Vector3D, w, x, y, z float64) (Pose, error) {
+	rotation, err := RotationFromQuaternion(w, x, y, z)
+	if err != nil {
+		return Pose{}, err
+	}
+	return Pose{Position: position, Orientation: rotation}, nil
+}
+
+// PoseFromRPY takes the orientation as roll, pitch and yaw angles, see `RotationFromRPY`
+func PoseFromRPY(position vectors.Vector3D, roll, pitch, yaw float64) Pose {
+	return Pose{Position: position, Orientation: RotationFromRPY(roll, pitch, yaw)}
+}
+
+func RotationFromQuaternion(w, x, y, z float64) (*arrays.Array2D, error) {
+	norm := math.Sqrt(w*w + x*x + y*y + z*z)
+	if norm == 0 {
+		return nil, fmt.Errorf("null quaternion")
+	}
+	w, x, y, z = w/norm, x/norm, y/norm, z/norm
+	return &arrays.Array2D{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}, nil
+}
+
+// RotationFromRPY rotates by `roll` about x, then `pitch` about y, then `yaw` about z,
+// all about the fixed axes, i.e. Rz(yaw)*Ry(pitch)*Rx(roll)
+func RotationFromRPY(roll, pitch, yaw float64) *arrays.Array2D {
+	cr, sr := math.Cos(roll), math.Sin(roll)
+	cp, sp := math.Cos(pitch), math.Sin(pitch)
+	cy, sy := math.Cos(yaw), math.Sin(yaw)
+	return &arrays.Array2D{
+		{cy * cp, cy*sp*sr - sy*cr, cy*sp*cr + sy*sr},
+		{sy * cp, sy*sp*sr + cy*cr, sy*sp*cr - cy*sr},
+		{-sp, cp * sr, cp * cr},
+	}
+}
+
+// RPYFromRotation is the inverse of `RotationFromRPY`, with pitch in [-π/2, π/2]
+// At pitch ±π/2 roll and yaw are not unique, and roll is taken as 0
+func RPYFromRotation(rotation *arrays.Array2D) (roll, pitch, yaw float64) {
+	pitch = math.Asin(math.Max(-1, math.Min(1, -rotation.GetValue(2, 0))))
+	if math.Abs(math.Cos(pitch)) < rotationTolerance {
+		return 0, pitch, math.Atan2(-rotation.GetValue(0, 1), rotation.GetValue(1, 1))
+	}
+	roll = math.Atan2(rotation.GetValue(2, 1), rotation.GetValue(2, 2))
+	yaw = math.Atan2(rotation.GetValue(1, 0), rotation.GetValue(0, 0))
+	return roll, pitch, yaw
+}
+
+// TransformationMatrix returns the homogeneous transformation that rotates by the orientation of
+// `p`, then translates to its position
+func (p Pose) TransformationMatrix() *arrays.Array2D {
+	transformation := arrays.Identity2D(4)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			transformation.SetValue(i, j, p.Orientation.GetValue(i, j))
+		}
+	}
+	transformation.SetValue(0, 3, p.Position.X)
+	transformation.SetValue(1, 3, p.Position.Y)
+	transformation.SetValue(2, 3, p.Position.Z)
+	return transformation
+}
+
+// RotationOf returns the rotation part of a homogeneous transformation matrix
+func RotationOf(transformation *arrays.Array2D) *arrays.Array2D {
+	rotation := arrays.NewArray2D(3, 3)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			rotation.SetValue(i, j, transformation.GetValue(i, j))
+		}
+	}
+	return rotation
+}
+
+// OrientationError is the angle, in radians from 0 to π, of the rotation taking `a` to `b`
+func OrientationError(a, b *arrays.Array2D) float64 {
+	// trace(aᵀb) = 1 + 2cos(angle)
+	trace := 0.0
+	for i := 0; i < 3; i++ {
+		for k := 0; k < 3; k++ {
+			trace += a.GetValue(k, i) * b.GetValue(k, i)
+		}
+	}
+	return math.Acos(math.Max(-1, math.Min(1, (trace-1)/2)))
+}
+
+// Error weighs the distance between the positions of `p` and `target` against the angle
+// between their orientations
+func (p Pose) Error(target Pose, positionWeight, orientationWeight float64) float64 {
+	return positionWeight*p.Position.Distance(target.Position) +
+		orientationWeight*OrientationError(p.Orientation, target.Orientation)
+}
+
+func transpose3(m *arrays.Array2D) *arrays.Array2D {
+	t := arrays.NewArray2D(3, 3)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			t.SetValue(j, i, m.GetValue(i, j))
+		}
+	}
+	return t
+}
+
+func determinant3(m *arrays.Array2D) float64 {
+	return m.GetValue(0, 0)*(m.GetValue(1, 1)*m.GetValue(2, 2)-m.GetValue(1, 2)*m.GetValue(2, 1)) -
+		m.GetValue(0, 1)*(m.GetValue(1, 0)*m.GetValue(2, 2)-m.GetValue(1, 2)*m.GetValue(2, 0)) +
+		m.GetValue(0, 2)*(m.GetValue(1, 0)*m.GetValue(2, 1)-m.GetValue(1, 1)*m.GetValue(2, 0))
+}
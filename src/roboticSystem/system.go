@@ -2,19 +2,43 @@ package roboticSystem
 
 import (
 	"arrays"
-	"fmt"
+	"errors"
 	"utils"
 	"vectors"
 )
 
+// Variable is a DH parameter of a link that is optimized, within `Space`
+type Variable struct {
+	Field DHField
+	Space utils.Range1D
+}
+
 type Link struct {
 	DHParameters DHParameters
-	ThetaSpace   utils.Range1D
+	// the DH parameters that are optimized, in the order they appear in agents
+	// e.g. theta for a revolute joint, d for a prismatic one, none for a fixed link
+	Variables []Variable
+	// Deprecated: use `Variables`; a link without variables and with bounds in `ThetaSpace` is
+	// taken as revolute, varying within it, so links built before `Variables` keep working
+	ThetaSpace utils.Range1D
+}
+
+// variables of the link, including the theta of links only giving `ThetaSpace`
+func (l Link) variables() []Variable {
+	if l.Variables == nil && (l.ThetaSpace.LowerBound != 0 || l.ThetaSpace.UpperBound != 0) {
+		return []Variable{{ThetaField, l.ThetaSpace}}
+	}
+	return l.Variables
 }
 
 type System struct {
 	BasePosition vectors.Vector3D
-	Links        []Link
+	// 3x3 rotation of the base, nil for none
+	BaseOrientation *arrays.Array2D
+	Links           []Link
+	Convention      DHConvention // defaults to `StandardDH`
+	// 4x4 transformation from the frame of the last link to the tool tip, nil for none
+	Tool *arrays.Array2D
 }
 
 func NewSystem(x, y, z float64) System {
@@ -24,21 +48,48 @@ func NewSystem(x, y, z float64) System {
 	}
 }
 
+// Copy returns a system that can be updated independently of `s`
+// Variables are shared, as updates only change DH parameters
+func (s *System) Copy() System {
+	links := make([]Link, s.Length())
+	copy(links, s.Links)
+	return System{
+		BasePosition:    s.BasePosition,
+		BaseOrientation: s.BaseOrientation,
+		Links:           links,
+		Convention:      s.Convention,
+		Tool:            s.Tool,
+	}
+}
+
 func (s *System) Length() int {
 	return len(s.Links)
 }
 
+// AddLink adds a link with a revolute joint, its theta varying within `space`
 func (s *System) AddLink(dh DHParameters, space utils.Range1D) {
+	s.AddLinkWithVariables(dh, Variable{ThetaField, space})
+	s.Links[len(s.Links)-1].ThetaSpace = space
+}
+
+// AddPrismaticLink adds a link with a prismatic joint, its d varying within `space`
+func (s *System) AddPrismaticLink(dh DHParameters, space utils.Range1D) {
+	s.AddLinkWithVariables(dh, Variable{DField, space})
+}
+
+// AddLinkWithVariables adds a link whose `variables` are optimized, in the given order
+// Without variables, the link is fixed
+func (s *System) AddLinkWithVariables(dh DHParameters, variables ...Variable) {
 	s.Links = append(s.Links, Link{
 		DHParameters: dh,
-		ThetaSpace:   space,
+		Variables:    variables,
 	})
 }
 
 func (s *System) AddLinks(dhs []DHParameters, spaces []utils.Range1D) error {
 	if len(dhs) != len(spaces) && len(spaces) != 1 {
 		msg := "invalid number of spaces for values. should be equal to number of parameter groups given, or 1"
-		return fmt.Errorf(msg)
+		return errors.New(msg)
 	}
 	for i := range dhs {
 		var space utils.Range1D
@@ -56,16 +107,82 @@ func (s *System) SetTheta(link int, theta float64) {
 	s.Links[link].DHParameters.Theta = theta
 }
 
+// number of variables of all links, i.e. the agent size
+func (s *System) VariableCount() int {
+	count := 0
+	for _, link := range s.Links {
+		count += len(link.variables())
+	}
+	return count
+}
+
+// UpdateVariables sets the variables of each link in turn from `agent`
+// example for a revolute link followed by a link with variable d and alpha: `agent = [θ0 d1 α1]`
+func (s *System) UpdateVariables(agent *arrays.Array1D) {
+	k := 0
+	for i := range s.Links {
+		link := &s.Links[i]
+		for _, variable := range link.variables() {
+			link.DHParameters.Set(variable.Field, agent.Get(k))
+			k++
+		}
+	}
+}
+
+// current values of the variables, in the layout `UpdateVariables` takes
+func (s *System) Variables() *arrays.Array1D {
+	values := make(arrays.Array1D, 0, s.VariableCount())
+	for _, link := range s.Links {
+		for _, variable := range link.variables() {
+			values = append(values, link.DHParameters.Get(variable.Field))
+		}
+	}
+	return &values
+}
+
+// search space of the variables, in the layout `UpdateVariables` takes
+func (s *System) VariableSpace() []utils.Range1D {
+	valueSpace := make([]utils.Range1D, 0, s.VariableCount())
+	for _, link := range s.Links {
+		for _, variable := range link.variables() {
+			valueSpace = append(valueSpace, variable.Space)
+		}
+	}
+	return valueSpace
+}
+
+// UpdateThetas sets the theta of each link in turn from `thetas`
+//
+// Deprecated: use `UpdateVariables`, which it matches for systems of revolute links only
 func (s *System) UpdateThetas(thetas *arrays.Array1D) {
 	for i, theta := range thetas.Items() {
 		s.SetTheta(i, theta)
 	}
 }
 
+// Thetas returns the theta of each link, in the layout `UpdateThetas` takes
+//
+// Deprecated: use `Variables`, which it matches for systems of revolute links only
+func (s *System) Thetas() *arrays.Array1D {
+	thetas := make(arrays.Array1D, s.Length())
+	for i, link := range s.Links {
+		thetas[i] = link.DHParameters.Theta
+	}
+	return &thetas
+}
+
+// GetThetaValueSpace returns the space theta varies within for each link, null for links
+// with a fixed theta
+//
+// Deprecated: use `VariableSpace`, which it matches for systems of revolute links only
 func (s *System) GetThetaValueSpace() []utils.Range1D {
 	valueSpace := make([]utils.Range1D, s.Length())
 	for i, link := range s.Links {
-		valueSpace[i] = link.ThetaSpace
+		for _, variable := range link.variables() {
+			if variable.Field == ThetaField {
+				valueSpace[i] = variable.Space
+			}
+		}
 	}
 	return valueSpace
 }
@@ -78,31 +195,60 @@ func (s *System) DHParameters() []DHParameters {
 	return dh
 }
 
+// pose of the base as a transformation matrix
+func (s *System) baseTransformation() *arrays.Array2D {
+	orientation := s.BaseOrientation
+	if orientation == nil {
+		orientation = arrays.Identity2D(3)
+	}
+	return Pose{Position: s.BasePosition, Orientation: orientation}.TransformationMatrix()
+}
+
 // Calculates position of the junctions for each link
 func (s *System) LinkPositions() []vectors.Vector3D {
 	linkPositions := make([]vectors.Vector3D, len(s.Links)+1)
 	linkPositions[0] = s.BasePosition
 	transformMatrices := make([]*arrays.Array2D, s.Length())
 	// first if T1
-	transformMatrices[0] = s.Links[0].DHParameters.TransformationMatrix()
+	transformMatrices[0] = s.baseTransformation().Multiply(s.Links[0].DHParameters.ConventionTransformationMatrix(s.Convention))
 	for i := 1; i < len(s.Links); i++ {
 		param := s.Links[i].DHParameters
 		// i-th is T1*T2*...*Ti
-		transformMatrices[i] = transformMatrices[i-1].Multiply(param.TransformationMatrix())
+		transformMatrices[i] = transformMatrices[i-1].Multiply(param.ConventionTransformationMatrix(s.Convention))
 	}
-	// link 1 -> T1*link0
-	// link 2 -> T1*T2*link0
-	// link i -> T1*T2*...*Ti*link0
+	// with T1 including the base pose
+	// link 1 -> T1*origin
+	// link 2 -> T1*T2*origin
+	// link i -> T1*T2*...*Ti*origin
+	origin := vectors.NewVector3D(0, 0, 0)
 	for i := 0; i < len(s.Links); i++ {
-		newPosition := linkPositions[0].Transform(transformMatrices[i])
+		newPosition := origin.Transform(transformMatrices[i])
 		linkPositions[i+1] = newPosition
 	}
 	return linkPositions
 }
 
+// Transformation chains the base pose, the transformation of each link and the tool
+func (s *System) Transformation() *arrays.Array2D {
+	transformationMatrix := s.baseTransformation().Multiply(ParametersToConventionTransformationMatrix(s.Convention, s.DHParameters()))
+	if s.Tool != nil {
+		transformationMatrix = transformationMatrix.Multiply(s.Tool)
+	}
+	return transformationMatrix
+}
+
 func (s *System) ManipulatorPosition() vectors.Vector3D {
-	transformationMatrix := ParametersToTransformationMatrix(s.DHParameters())
-	return s.BasePosition.Transform(transformationMatrix)
+	return vectors.NewVector3D(0, 0, 0).Transform(s.Transformation())
+}
+
+// position and orientation of the tool tip, or the extremity of the last link without a tool,
+// relative to the world frame the base pose is given in
+func (s *System) ManipulatorPose() Pose {
+	transformationMatrix := s.Transformation()
+	return Pose{
+		Position:    vectors.NewVector3D(0, 0, 0).Transform(transformationMatrix),
+		Orientation: RotationOf(transformationMatrix),
+	}
 }
 
 //func SystemFromArray1D(array *arrays.Array1D) System {
@@ -0,0 +1,26 @@
+package roboticSystem
+
+import (
+	"math"
+	"testing"
+	"utils"
+	"vectors"
+)
+
+func TestBasePose(t *testing.T) {
+	system := NewSystem(1, 0, 0)
+	system.BaseOrientation = RotationFromRPY(0, 0, math.Pi/2)
+	system.AddLink(DHParameters{R: 0.5}, utils.Range1D{LowerBound: -math.Pi, UpperBound: math.Pi})
+
+	expected := vectors.NewVector3D(1, 0.5, 0)
+	if position := system.ManipulatorPosition(); position.Distance(expected) > 1e-12 {
+		t.Errorf("manipulator position %s, expected %s", position, expected)
+	}
+	if pose := system.ManipulatorPose(); pose.Position.Distance(expected) > 1e-12 {
+		t.Errorf("manipulator pose position %s, expected %s", pose.Position, expected)
+	}
+	positions := system.LinkPositions()
+	if positions[0].Distance(system.BasePosition) > 1e-12 || positions[1].Distance(expected) > 1e-12 {
+		t.Errorf("link positions %v, expected [%s %s]", positions, system.BasePosition, expected)
+	}
+}
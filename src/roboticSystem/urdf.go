@@ -0,0 +1,443 @@
+package roboticSystem
+
+import (
+	"arrays"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"utils"
+)
+
+// below this, lengths and angles derived from a URDF are taken as 0
+const urdfTolerance = 1e-9
+
+// http://wiki.ros.org/urdf/XML
+type urdfRobot struct {
+	Name   string      `xml:"name,attr"`
+	Links  []urdfLink  `xml:"link"`
+	Joints []urdfJoint `xml:"joint"`
+	// other top level elements, only checked for closed loops
+	Others []struct {
+		XMLName xml.Name
+		Name    string `xml:"name,attr"`
+	} `xml:",any"`
+}
+
+type urdfLink struct {
+	Name string `xml:"name,attr"`
+}
+
+type urdfJoint struct {
+	Name   string `xml:"name,attr"`
+	Type   string `xml:"type,attr"`
+	Parent struct {
+		Link string `xml:"link,attr"`
+	} `xml:"parent"`
+	Child struct {
+		Link string `xml:"link,attr"`
+	} `xml:"child"`
+	Origin *struct {
+		XYZ string `xml:"xyz,attr"`
+		RPY string `xml:"rpy,attr"`
+	} `xml:"origin"`
+	Axis *struct {
+		XYZ string `xml:"xyz,attr"`
+	} `xml:"axis"`
+	Limit *struct {
+		Lower float64 `xml:"lower,attr"`
+		Upper float64 `xml:"upper,attr"`
+	} `xml:"limit"`
+	Mimic *struct {
+		Joint string `xml:"joint,attr"`
+	} `xml:"mimic"`
+}
+
+// LoadURDF reads a URDF file, see `ParseURDF`
+func LoadURDF(path, tip string) (System, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return System{}, err
+	}
+	system, err := ParseURDF(data, tip)
+	if err != nil {
+		return System{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return system, nil
+}
+
+// ParseURDF builds a system from the serial chain of a URDF robot going from its root link to
+// the `tip` link, which may be empty if the robot does not branch
+// Revolute, continuous and prismatic joints become links of the system, in the standard DH
+// convention, with their limits carried over (continuous joints wrap around within [-π, π])
+// Fixed joints are merged into the links around them. If the root frame or the tip frame do not
+// fit the DH convention, the system gets an extra fixed link at its start, and a tool, respectively
+// Variables are offset by the joint's DH θ or d, the variables of the system as imported being the
+// zero configuration of the URDF; `URDFJointValues` maps them back to joint values
+// Closed loops, and mimic, floating and planar joints along the chain, are reported as errors
+// Visual, collision, inertial and other non-kinematic elements are ignored
+func ParseURDF(data []byte, tip string) (System, error) {
+	var robot urdfRobot
+	if err := xml.Unmarshal(data, &robot); err != nil {
+		return System{}, fmt.Errorf("invalid URDF: %w", err)
+	}
+	chain, err := robot.chain(tip)
+	if err != nil {
+		return System{}, err
+	}
+
+	// frames of the chain at the zero configuration, relative to the root link
+	var axes []jointAxis
+	tipFrame := arrays.Identity2D(4)
+	for _, joint := range chain {
+		origin, err := joint.originMatrix()
+		if err != nil {
+			return System{}, err
+		}
+		tipFrame = tipFrame.Multiply(origin)
+		if joint.Type == "fixed" {
+			continue
+		}
+		axis, err := joint.axis()
+		if err != nil {
+			return System{}, err
+		}
+		direction := normalize(rotate(tipFrame, axis))
+		axes = append(axes, jointAxis{joint, translationOf(tipFrame), direction})
+	}
+	if len(axes) == 0 {
+		return System{}, fmt.Errorf("the chain to link %q has no revolute or prismatic joints", chain[len(chain)-1].Child.Link)
+	}
+
+	system := NewSystem(0, 0, 0)
+	// frame i has its z axis along joint i+1, frame -1 is the root frame
+	previous := dhFrame{x: vector{1, 0, 0}, y: vector{0, 1, 0}, z: vector{0, 0, 1}}
+	current := nextDHFrame(previous, axes[0].point, axes[0].direction)
+	if dh := dhBetween(previous, current); dh != (DHParameters{}) {
+		system.AddLinkWithVariables(dh)
+	}
+	for i, axis := range axes {
+		previous = current
+		if i+1 < len(axes) {
+			current = nextDHFrame(previous, axes[i+1].point, axes[i+1].direction)
+		} else {
+			current = lastDHFrame(previous, tipFrame)
+		}
+		dh := dhBetween(previous, current)
+		switch axis.joint.Type {
+		case "prismatic":
+			system.AddPrismaticLink(dh, utils.Range1D{
+				LowerBound: dh.D + axis.joint.Limit.Lower,
+				UpperBound: dh.D + axis.joint.Limit.Upper,
+			})
+		case "continuous":
+			system.AddLink(dh, utils.Range1D{LowerBound: dh.Theta - math.Pi, UpperBound: dh.Theta + math.Pi, Policy: utils.Wrap})
+		default:
+			system.AddLink(dh, utils.Range1D{
+				LowerBound: dh.Theta + axis.joint.Limit.Lower,
+				UpperBound: dh.Theta + axis.joint.Limit.Upper,
+			})
+		}
+	}
+	if tool := rigidInverse(current.matrix()).Multiply(tipFrame); !isIdentity(tool) {
+		system.Tool = tool
+	}
+	return system, nil
+}
+
+// URDFJointValues maps `agent`, in the variable layout of a system imported by `ParseURDF`, back
+// to the values of the URDF joints along its chain, given `zero`, the variables of the system as
+// it was imported
+func URDFJointValues(agent, zero *arrays.Array1D) *arrays.Array1D {
+	return agent.Subtract(zero)
+}
+
+// joints from the root link to `tip`, after checking the robot for closed loops and the joints of
+// the chain for unsupported types and mimic joints
+func (r urdfRobot) chain(tip string) ([]urdfJoint, error) {
+	var errs []error
+	parentJoint := make(map[string]urdfJoint)
+	children := make(map[string][]string)
+	for _, other := range r.Others {
+		if other.XMLName.Local == "loop_joint" {
+			errs = append(errs, fmt.Errorf("loop joint %q: closed loops are not supported", other.Name))
+		}
+	}
+	for _, joint := range r.Joints {
+		if other, ok := parentJoint[joint.Child.Link]; ok {
+			errs = append(errs, fmt.Errorf("link %q is the child of joints %q and %q: closed loops are not supported",
+				joint.Child.Link, other.Name, joint.Name))
+			continue
+		}
+		parentJoint[joint.Child.Link] = joint
+		children[joint.Parent.Link] = append(children[joint.Parent.Link], joint.Child.Link)
+	}
+	var roots, leaves []string
+	links := make(map[string]bool)
+	for _, link := range r.Links {
+		links[link.Name] = true
+		if _, ok := parentJoint[link.Name]; !ok {
+			roots = append(roots, link.Name)
+		}
+		if len(children[link.Name]) == 0 {
+			leaves = append(leaves, link.Name)
+		}
+	}
+	if len(roots) != 1 {
+		errs = append(errs, fmt.Errorf("expected a single root link, found %v", roots))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	switch {
+	case tip == "" && len(leaves) != 1:
+		return nil, fmt.Errorf("the robot branches, choose a tip link among %v", leaves)
+	case tip == "":
+		tip = leaves[0]
+	case !links[tip]:
+		return nil, fmt.Errorf("unknown tip link %q", tip)
+	}
+	var chain []urdfJoint
+	visited := make(map[string]bool)
+	for link := tip; link != roots[0]; {
+		if visited[link] {
+			return nil, fmt.Errorf("link %q: closed loops are not supported", link)
+		}
+		visited[link] = true
+		joint, ok := parentJoint[link]
+		if !ok {
+			return nil, fmt.Errorf("tip link %q is not connected to root link %q", tip, roots[0])
+		}
+		chain = append([]urdfJoint{joint}, chain...)
+		link = joint.Parent.Link
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("tip link %q is the root link", tip)
+	}
+
+	for _, joint := range chain {
+		switch joint.Type {
+		case "revolute", "prismatic":
+			if joint.Limit == nil {
+				errs = append(errs, fmt.Errorf("joint %q: %s joints require a limit", joint.Name, joint.Type))
+			}
+		case "continuous", "fixed":
+		case "floating", "planar":
+			errs = append(errs, fmt.Errorf("joint %q: %s joints are not supported", joint.Name, joint.Type))
+		default:
+			errs = append(errs, fmt.Errorf("joint %q: unknown joint type %q", joint.Name, joint.Type))
+		}
+		if joint.Mimic != nil {
+			errs = append(errs, fmt.Errorf("joint %q: mimic joints are not supported (mimics %q)", joint.Name, joint.Mimic.Joint))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return chain, nil
+}
+
+// transformation from the parent link frame to the joint frame
+func (j urdfJoint) originMatrix() (*arrays.Array2D, error) {
+	xyz, rpy := vector{}, vector{}
+	if j.Origin != nil {
+		var err error
+		if xyz, err = parseVector(j.Origin.XYZ, vector{}); err != nil {
+			return nil, fmt.Errorf("joint %q: origin xyz: %w", j.Name, err)
+		}
+		if rpy, err = parseVector(j.Origin.RPY, vector{}); err != nil {
+			return nil, fmt.Errorf("joint %q: origin rpy: %w", j.Name, err)
+		}
+	}
+	rotation := RotationFromRPY(rpy[0], rpy[1], rpy[2])
+	matrix := arrays.Identity2D(4)
+	for i := 0; i < 3; i++ {
+		for k := 0; k < 3; k++ {
+			matrix.SetValue(i, k, rotation.GetValue(i, k))
+		}
+		matrix.SetValue(i, 3, xyz[i])
+	}
+	return matrix, nil
+}
+
+// axis of the joint in its own frame, which defaults to x
+func (j urdfJoint) axis() (vector, error) {
+	value := ""
+	if j.Axis != nil {
+		value = j.Axis.XYZ
+	}
+	axis, err := parseVector(value, vector{1, 0, 0})
+	if err != nil {
+		return vector{}, fmt.Errorf("joint %q: axis xyz: %w", j.Name, err)
+	}
+	if norm(axis) < urdfTolerance {
+		return vector{}, fmt.Errorf("joint %q: null axis", j.Name)
+	}
+	return axis, nil
+}
+
+func parseVector(value string, fallback vector) (vector, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return fallback, nil
+	}
+	if len(fields) != 3 {
+		return vector{}, fmt.Errorf("expected 3 values, got %q", value)
+	}
+	var v vector
+	for i, field := range fields {
+		number, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return vector{}, fmt.Errorf("invalid number %q", field)
+		}
+		v[i] = number
+	}
+	return v, nil
+}
+
+// line of a movable joint, relative to the root link, at the zero configuration
+type jointAxis struct {
+	joint     urdfJoint
+	point     vector
+	direction vector
+}
+
+type dhFrame struct {
+	origin, x, y, z vector
+}
+
+func (f dhFrame) matrix() *arrays.Array2D {
+	return &arrays.Array2D{
+		{f.x[0], f.y[0], f.z[0], f.origin[0]},
+		{f.x[1], f.y[1], f.z[1], f.origin[1]},
+		{f.x[2], f.y[2], f.z[2], f.origin[2]},
+		{0, 0, 0, 1},
+	}
+}
+
+// DH frame with its z axis along the line through `point` with `direction`, following `previous`:
+// its x axis is the common normal of both z axes, so the transformation between them is a DH one
+func nextDHFrame(previous dhFrame, point, direction vector) dhFrame {
+	z := direction
+	normal := cross(previous.z, z)
+	var x, origin vector
+	if norm(normal) > urdfTolerance {
+		// closest points of both z axes
+		r := sub(previous.origin, point)
+		b, d, e := dot(previous.z, z), dot(previous.z, r), dot(z, r)
+		s := (b*e - d) / (1 - b*b)
+		t := (e - b*d) / (1 - b*b)
+		origin = add(point, scale(z, t))
+		x = normalize(normal)
+		// point x from the previous z axis to the new one, so that r >= 0
+		if dot(sub(origin, add(previous.origin, scale(previous.z, s))), x) < 0 {
+			x = scale(x, -1)
+		}
+	} else {
+		// parallel axes: take the common normal through the previous origin
+		r := sub(point, previous.origin)
+		offset := sub(r, scale(previous.z, dot(r, previous.z)))
+		if norm(offset) > urdfTolerance {
+			x = normalize(offset)
+		} else {
+			x = previous.x
+		}
+		origin = add(previous.origin, offset)
+	}
+	return dhFrame{origin, x, cross(z, x), z}
+}
+
+// last DH frame, following `previous`, at the origin of `tip` if possible so only the rotation
+// of the tip is left for the tool
+func lastDHFrame(previous dhFrame, tip *arrays.Array2D) dhFrame {
+	origin := translationOf(tip)
+	r := sub(origin, previous.origin)
+	offset := sub(r, scale(previous.z, dot(r, previous.z)))
+	if norm(offset) <= urdfTolerance {
+		return dhFrame{origin, previous.x, previous.y, previous.z}
+	}
+	x := normalize(offset)
+	tipZ := rotate(tip, vector{0, 0, 1})
+	z := sub(tipZ, scale(x, dot(tipZ, x)))
+	if norm(z) <= urdfTolerance {
+		z = previous.z
+	}
+	z = normalize(z)
+	return dhFrame{origin, x, cross(z, x), z}
+}
+
+// DH parameters of the transformation from `previous` to `next`, whose x axis must be
+// perpendicular to the z axis of `previous` and intersect it
+func dhBetween(previous, next dhFrame) DHParameters {
+	m := rigidInverse(previous.matrix()).Multiply(next.matrix())
+	theta := math.Atan2(m.GetValue(1, 0), m.GetValue(0, 0))
+	alpha := math.Atan2(m.GetValue(2, 1), m.GetValue(2, 2))
+	clean := func(value float64) float64 {
+		if math.Abs(value) < urdfTolerance {
+			return 0
+		}
+		return value
+	}
+	return DHParameters{
+		Theta: clean(theta),
+		D:     clean(m.GetValue(2, 3)),
+		R:     clean(m.GetValue(0, 3)*math.Cos(theta) + m.GetValue(1, 3)*math.Sin(theta)),
+		Alpha: clean(alpha),
+	}
+}
+
+func rigidInverse(m *arrays.Array2D) *arrays.Array2D {
+	inverse := arrays.Identity2D(4)
+	for i := 0; i < 3; i++ {
+		for k := 0; k < 3; k++ {
+			inverse.SetValue(i, k, m.GetValue(k, i))
+		}
+	}
+	t := translationOf(m)
+	for i := 0; i < 3; i++ {
+		inverse.SetValue(i, 3, -(m.GetValue(0, i)*t[0] + m.GetValue(1, i)*t[1] + m.GetValue(2, i)*t[2]))
+	}
+	return inverse
+}
+
+func isIdentity(m *arrays.Array2D) bool {
+	identity := arrays.Identity2D(4)
+	for i := 0; i < 4; i++ {
+		for k := 0; k < 4; k++ {
+			if math.Abs(m.GetValue(i, k)-identity.GetValue(i, k)) > urdfTolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type vector [3]float64
+
+func translationOf(m *arrays.Array2D) vector {
+	return vector{m.GetValue(0, 3), m.GetValue(1, 3), m.GetValue(2, 3)}
+}
+
+// rotates `v` by the rotation part of the transformation `m`
+func rotate(m *arrays.Array2D, v vector) vector {
+	var rotated vector
+	for i := 0; i < 3; i++ {
+		rotated[i] = m.GetValue(i, 0)*v[0] + m.GetValue(i, 1)*v[1] + m.GetValue(i, 2)*v[2]
+	}
+	return rotated
+}
+
+func add(a, b vector) vector           { return vector{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func sub(a, b vector) vector           { return vector{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func scale(a vector, c float64) vector { return vector{a[0] * c, a[1] * c, a[2] * c} }
+func dot(a, b vector) float64          { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func norm(a vector) float64            { return math.Sqrt(dot(a, a)) }
+func normalize(a vector) vector        { return scale(a, 1/norm(a)) }
+
+func cross(a, b vector) vector {
+	return vector{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
@@ -0,0 +1,212 @@
+package roboticSystem
+
+import (
+	"arrays"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"utils"
+	"vectors"
+)
+
+// an arm with offsets between its joint axes, a prismatic and a continuous joint, a fixed mount
+// and tool, and a gripper branching off the chain
+const testURDF = `<?xml version="1.0"?>
+<robot name="test_arm">
+  <link name="world"/>
+  <link name="base_link"/>
+  <link name="shoulder"/>
+  <link name="upper_arm"/>
+  <link name="forearm"/>
+  <link name="wrist1"/>
+  <link name="slider"/>
+  <link name="wrist2"/>
+  <link name="flange"/>
+  <link name="tool0"/>
+  <link name="finger"/>
+  <joint name="mount" type="fixed">
+    <parent link="world"/><child link="base_link"/>
+    <origin xyz="0.2 -0.1 0.05" rpy="0 0 0.3"/>
+  </joint>
+  <joint name="shoulder_pan" type="revolute">
+    <parent link="base_link"/><child link="shoulder"/>
+    <origin xyz="0 0 0.089" rpy="0 0 0"/><axis xyz="0 0 1"/>
+    <limit lower="-3.0" upper="3.0" effort="150" velocity="3.15"/>
+  </joint>
+  <joint name="shoulder_lift" type="revolute">
+    <parent link="shoulder"/><child link="upper_arm"/>
+    <origin xyz="0 0.136 0" rpy="0 1.5707963 0"/><axis xyz="0 1 0"/>
+    <limit lower="-2" upper="2" effort="150" velocity="3.15"/>
+  </joint>
+  <joint name="elbow" type="revolute">
+    <parent link="upper_arm"/><child link="forearm"/>
+    <origin xyz="0 -0.12 0.425" rpy="0 0 0"/><axis xyz="0 1 0"/>
+    <limit lower="-2.5" upper="2.5" effort="150" velocity="3.15"/>
+  </joint>
+  <joint name="wrist_1" type="continuous">
+    <parent link="forearm"/><child link="wrist1"/>
+    <origin xyz="0 0 0.392" rpy="0 1.5707963 0"/><axis xyz="0 1 0"/>
+  </joint>
+  <joint name="telescope" type="prismatic">
+    <parent link="wrist1"/><child link="slider"/>
+    <origin xyz="0.01 0.093 0" rpy="0.2 0 0"/><axis xyz="0 0 -1"/>
+    <limit lower="0" upper="0.1" effort="10" velocity="1"/>
+  </joint>
+  <joint name="wrist_2" type="revolute">
+    <parent link="slider"/><child link="wrist2"/>
+    <origin xyz="0 0 0.0946" rpy="0 0 0"/><axis xyz="0.3 0 1"/>
+    <limit lower="-3" upper="3" effort="28" velocity="3.2"/>
+  </joint>
+  <joint name="flange_joint" type="fixed">
+    <parent link="wrist2"/><child link="flange"/>
+    <origin xyz="0 0.0823 0" rpy="0 0 1.5707963"/>
+  </joint>
+  <joint name="tool_joint" type="fixed">
+    <parent link="flange"/><child link="tool0"/>
+    <origin xyz="0.05 0 0.1" rpy="-1.5707963 0 -1.5707963"/>
+  </joint>
+  <joint name="finger_joint" type="prismatic">
+    <parent link="flange"/><child link="finger"/>
+    <origin xyz="0 0.02 0.05"/><axis xyz="0 1 0"/><limit lower="0" upper="0.04"/>
+  </joint>
+</robot>`
+
+// joints of `testURDF` from world to tool0, as origin xyz, rpy and axis, nil for fixed joints
+var testURDFChain = []struct {
+	xyz, rpy, axis []float64
+	prismatic      bool
+}{
+	{[]float64{0.2, -0.1, 0.05}, []float64{0, 0, 0.3}, nil, false},
+	{[]float64{0, 0, 0.089}, []float64{0, 0, 0}, []float64{0, 0, 1}, false},
+	{[]float64{0, 0.136, 0}, []float64{0, 1.5707963, 0}, []float64{0, 1, 0}, false},
+	{[]float64{0, -0.12, 0.425}, []float64{0, 0, 0}, []float64{0, 1, 0}, false},
+	{[]float64{0, 0, 0.392}, []float64{0, 1.5707963, 0}, []float64{0, 1, 0}, false},
+	{[]float64{0.01, 0.093, 0}, []float64{0.2, 0, 0}, []float64{0, 0, -1}, true},
+	{[]float64{0, 0, 0.0946}, []float64{0, 0, 0}, []float64{0.3, 0, 1}, false},
+	{[]float64{0, 0.0823, 0}, []float64{0, 0, 1.5707963}, nil, false},
+	{[]float64{0.05, 0, 0.1}, []float64{-1.5707963, 0, -1.5707963}, nil, false},
+}
+
+func TestParseURDFForwardKinematics(t *testing.T) {
+	system, err := ParseURDF([]byte(testURDF), "tool0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if system.VariableCount() != 6 {
+		t.Fatalf("got %d variables, expected one per moving joint, 6", system.VariableCount())
+	}
+	offsets := system.Variables()
+	rng := rand.New(rand.NewSource(1))
+	for k := 0; k < 50; k++ {
+		agent := make(arrays.Array1D, system.VariableCount())
+		for j, space := range system.VariableSpace() {
+			agent[j] = utils.RandomInRange(rng, space)
+		}
+		s := system.Copy()
+		s.UpdateVariables(&agent)
+		joints := URDFJointValues(&agent, offsets)
+		pose, expected := s.ManipulatorPose(), referenceForwardKinematics(*joints)
+		if d := pose.Position.Distance(expected.Position); d > 1e-9 {
+			t.Errorf("position %s at %v, expected %s", pose.Position, agent, expected.Position)
+		}
+		// the arc cosine in `OrientationError` only resolves small angles to about 1e-8
+		if d := OrientationError(pose.Orientation, expected.Orientation); d > 1e-6 {
+			t.Errorf("orientation off by %g rad at %v", d, agent)
+		}
+	}
+}
+
+// forward kinematics of `testURDFChain`, moving each joint by rotating about or translating along
+// its axis
+func referenceForwardKinematics(values arrays.Array1D) Pose {
+	transformation := arrays.Identity2D(4)
+	k := 0
+	for _, joint := range testURDFChain {
+		origin := PoseFromRPY(vectors.NewVector3D(joint.xyz[0], joint.xyz[1], joint.xyz[2]), joint.rpy[0], joint.rpy[1], joint.rpy[2])
+		transformation = transformation.Multiply(origin.TransformationMatrix())
+		if joint.axis == nil {
+			continue
+		}
+		n := math.Sqrt(joint.axis[0]*joint.axis[0] + joint.axis[1]*joint.axis[1] + joint.axis[2]*joint.axis[2])
+		x, y, z := joint.axis[0]/n, joint.axis[1]/n, joint.axis[2]/n
+		motion := arrays.Identity2D(4)
+		if joint.prismatic {
+			motion.SetValue(0, 3, x*values[k])
+			motion.SetValue(1, 3, y*values[k])
+			motion.SetValue(2, 3, z*values[k])
+		} else {
+			// Rodrigues' rotation formula
+			c, s := math.Cos(values[k]), math.Sin(values[k])
+			rotation := arrays.Array2D{
+				{c + x*x*(1-c), x*y*(1-c) - z*s, x*z*(1-c) + y*s},
+				{y*x*(1-c) + z*s, c + y*y*(1-c), y*z*(1-c) - x*s},
+				{z*x*(1-c) - y*s, z*y*(1-c) + x*s, c + z*z*(1-c)},
+			}
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 3; j++ {
+					motion.SetValue(i, j, rotation.GetValue(i, j))
+				}
+			}
+		}
+		transformation = transformation.Multiply(motion)
+		k++
+	}
+	return Pose{
+		Position:    vectors.NewVector3D(transformation.GetValue(0, 3), transformation.GetValue(1, 3), transformation.GetValue(2, 3)),
+		Orientation: RotationOf(transformation),
+	}
+}
+
+func TestParseURDFErrors(t *testing.T) {
+	// a two joint arm, with `extra` added to its elements
+	robot := func(secondJoint, extra string) string {
+		return `<robot name="r">
+  <link name="base"/><link name="upper"/><link name="lower"/>
+  <joint name="shoulder" type="revolute">
+    <parent link="base"/><child link="upper"/><axis xyz="0 0 1"/><limit lower="-1" upper="1"/>
+  </joint>
+  <joint name="elbow" type="` + secondJoint + `">
+    <parent link="upper"/><child link="lower"/><origin xyz="0.5 0 0"/><axis xyz="0 0 1"/><limit lower="-1" upper="1"/>
+    ` + extra + `
+  </joint>
+</robot>`
+	}
+	cases := []struct {
+		name, urdf, tip, message string
+	}{
+		{"mimic joint", robot("revolute", `<mimic joint="shoulder" multiplier="2"/>`), "",
+			`joint "elbow": mimic joints are not supported (mimics "shoulder")`},
+		{"floating joint", robot("floating", ""), "", `joint "elbow": floating joints are not supported`},
+		{"planar joint", robot("planar", ""), "", `joint "elbow": planar joints are not supported`},
+		{"unknown joint type", robot("ball", ""), "", `joint "elbow": unknown joint type "ball"`},
+		{"link with two parents", strings.Replace(robot("revolute", ""), "</robot>", `<joint name="closing" type="fixed">
+    <parent link="base"/><child link="lower"/>
+  </joint>
+</robot>`, 1), "", `link "lower" is the child of joints "elbow" and "closing": closed loops are not supported`},
+		{"loop joint", strings.Replace(robot("revolute", ""), "</robot>", `<loop_joint name="loop" type="revolute"/></robot>`, 1), "",
+			`loop joint "loop": closed loops are not supported`},
+		{"branch without tip", strings.Replace(robot("revolute", ""), "</robot>", `<link name="finger"/>
+  <joint name="grip" type="prismatic">
+    <parent link="upper"/><child link="finger"/><limit lower="0" upper="0.1"/>
+  </joint>
+</robot>`, 1), "", "the robot branches, choose a tip link among [lower finger]"},
+		{"unknown tip", robot("revolute", ""), "hand", `unknown tip link "hand"`},
+	}
+	for _, c := range cases {
+		_, err := ParseURDF([]byte(c.urdf), c.tip)
+		if err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("%s: got error %v, expected %q", c.name, err, c.message)
+		}
+	}
+
+	// joints off the chain to the tip are not checked
+	branched := strings.Replace(robot("revolute", ""), "</robot>", `<link name="finger"/>
+  <joint name="grip" type="prismatic">
+    <parent link="upper"/><child link="finger"/><limit lower="0" upper="0.1"/><mimic joint="elbow"/>
+  </joint>
+</robot>`, 1)
+	if _, err := ParseURDF([]byte(branched), "lower"); err != nil {
+		t.Errorf("mimic joint off the chain: %v", err)
+	}
+}
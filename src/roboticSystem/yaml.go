@@ -0,0 +1,390 @@
+package roboticSystem
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Robot definitions in YAML are read with a parser of the subset of YAML such files need, as the
+// standard library has none: block mappings and sequences, flow sequences and mappings on a single
+// line, plain and quoted scalars, and comments
+// Anchors, aliases, tags, block scalars and multiple documents are not supported
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	next  int
+}
+
+var yamlNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// parseYAML returns the document in `data` as the values encoding/json decodes to, i.e. nil,
+// bool, float64, string, []interface{} and map[string]interface{}
+func parseYAML(data []byte) (interface{}, error) {
+	var p yamlParser
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if text == "" || (len(p.lines) == 0 && text == "---") {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{number: i + 1, indent: len(line) - len(text), text: text})
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+	value, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.next < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.next].number)
+	}
+	return value, nil
+}
+
+// parses the node starting at the next line, indented by `indent`
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	line := p.lines[p.next]
+	if isYAMLSequenceItem(line.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitYAMLKey(line.text); ok {
+		return p.parseMapping(indent)
+	}
+	p.next++
+	return parseYAMLFlow(line)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	sequence := []interface{}{}
+	for p.next < len(p.lines) && p.lines[p.next].indent >= indent {
+		line := p.lines[p.next]
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if !isYAMLSequenceItem(line.text) {
+			break
+		}
+		item := strings.TrimLeft(line.text[1:], " ")
+		var value interface{}
+		var err error
+		if item == "" {
+			p.next++
+			value, err = p.parseNested(indent, false)
+		} else {
+			// the rest of the line is parsed as if it started a block of its own, e.g. the first
+			// key of a mapping whose other keys are on the following lines
+			p.lines[p.next] = yamlLine{number: line.number, indent: line.indent + len(line.text) - len(item), text: item}
+			value, err = p.parseBlock(p.lines[p.next].indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, value)
+	}
+	return sequence, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := map[string]interface{}{}
+	for p.next < len(p.lines) && p.lines[p.next].indent >= indent {
+		line := p.lines[p.next]
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\", got %q", line.number, line.text)
+		}
+		if _, duplicate := mapping[key]; duplicate {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+		p.next++
+		var value interface{}
+		var err error
+		if rest == "" {
+			// sequences may be indented as much as their key
+			value, err = p.parseNested(indent, true)
+		} else {
+			value, err = parseYAMLFlow(yamlLine{number: line.number, indent: line.indent, text: rest})
+		}
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = value
+	}
+	return mapping, nil
+}
+
+// parses the node nested under a line indented by `indent` with nothing after its key or dash,
+// which is null if the next line is not indented further
+func (p *yamlParser) parseNested(indent int, sameIndentSequence bool) (interface{}, error) {
+	if p.next == len(p.lines) {
+		return nil, nil
+	}
+	line := p.lines[p.next]
+	if line.indent > indent || (sameIndentSequence && line.indent == indent && isYAMLSequenceItem(line.text)) {
+		return p.parseBlock(line.indent)
+	}
+	return nil, nil
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splits `key: value` at the first colon followed by a space, outside of quotes and brackets
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ':' && depth == 0 && (i+1 == len(text) || text[i+1] == ' '):
+			key = strings.TrimSpace(text[:i])
+			if unquoted, err := unquoteYAML(key); err == nil {
+				key = unquoted
+			}
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// removes a comment, i.e. a `#` at the start of the line or after a space, outside of quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parses a value written on a single line: a scalar, or a flow sequence or mapping
+func parseYAMLFlow(line yamlLine) (interface{}, error) {
+	s := yamlFlowScanner{text: line.text}
+	value, err := s.value()
+	if err == nil {
+		s.skipSpaces()
+		if s.position < len(s.text) {
+			err = fmt.Errorf("unexpected %q", s.text[s.position:])
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %v", line.number, err)
+	}
+	return value, nil
+}
+
+type yamlFlowScanner struct {
+	text     string
+	position int
+	// nesting of flow collections, within which commas and closing brackets end plain scalars
+	depth int
+}
+
+func (s *yamlFlowScanner) skipSpaces() {
+	for s.position < len(s.text) && s.text[s.position] == ' ' {
+		s.position++
+	}
+}
+
+func (s *yamlFlowScanner) value() (interface{}, error) {
+	s.skipSpaces()
+	if s.position == len(s.text) {
+		return nil, nil
+	}
+	switch c := s.text[s.position]; c {
+	case '[':
+		return s.sequence()
+	case '{':
+		return s.mapping()
+	case '"', '\'':
+		return s.quoted()
+	case '|', '>', '&', '*', '!', '%', '@', '`':
+		return nil, fmt.Errorf("unsupported YAML syntax %q", c)
+	}
+	start := s.position
+	for s.position < len(s.text) {
+		c := s.text[s.position]
+		if s.depth > 0 && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+		if c == ':' && (s.position+1 == len(s.text) || s.text[s.position+1] == ' ') {
+			if s.depth == 0 {
+				return nil, fmt.Errorf("unexpected mapping in a value")
+			}
+			break
+		}
+		s.position++
+	}
+	return plainYAMLScalar(strings.TrimSpace(s.text[start:s.position])), nil
+}
+
+func (s *yamlFlowScanner) quoted() (interface{}, error) {
+	quote := s.text[s.position]
+	end := s.position + 1
+	for ; end < len(s.text); end++ {
+		if s.text[end] == '\\' && quote == '"' {
+			end++
+			continue
+		}
+		if s.text[end] == quote {
+			// '' is an escaped quote within single quotes
+			if quote == '\'' && end+1 < len(s.text) && s.text[end+1] == '\'' {
+				end++
+				continue
+			}
+			break
+		}
+	}
+	if end >= len(s.text) {
+		return nil, fmt.Errorf("unterminated string")
+	}
+	value, err := unquoteYAML(s.text[s.position : end+1])
+	s.position = end + 1
+	return value, err
+}
+
+func (s *yamlFlowScanner) sequence() (interface{}, error) {
+	s.position++
+	s.depth++
+	sequence := []interface{}{}
+	for {
+		s.skipSpaces()
+		if s.position < len(s.text) && s.text[s.position] == ']' && len(sequence) == 0 {
+			break
+		}
+		value, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, value)
+		if done, err := s.separator(']'); err != nil || done {
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	s.position++
+	s.depth--
+	return sequence, nil
+}
+
+func (s *yamlFlowScanner) mapping() (interface{}, error) {
+	s.position++
+	s.depth++
+	mapping := map[string]interface{}{}
+	for {
+		s.skipSpaces()
+		if s.position < len(s.text) && s.text[s.position] == '}' && len(mapping) == 0 {
+			break
+		}
+		key, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			name = fmt.Sprint(key)
+		}
+		s.skipSpaces()
+		if s.position == len(s.text) || s.text[s.position] != ':' {
+			return nil, fmt.Errorf("expected \":\" after key %q", name)
+		}
+		if _, duplicate := mapping[name]; duplicate {
+			return nil, fmt.Errorf("duplicate key %q", name)
+		}
+		s.position++
+		value, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		mapping[name] = value
+		if done, err := s.separator('}'); err != nil || done {
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	s.position++
+	s.depth--
+	return mapping, nil
+}
+
+// consumes the comma between two items of a flow collection, telling whether `closing` came instead
+func (s *yamlFlowScanner) separator(closing byte) (bool, error) {
+	s.skipSpaces()
+	if s.position == len(s.text) {
+		return false, fmt.Errorf("unterminated flow collection, which must fit on a single line")
+	}
+	switch s.text[s.position] {
+	case closing:
+		return true, nil
+	case ',':
+		s.position++
+		return false, nil
+	}
+	return false, fmt.Errorf("expected \",\" or %q, got %q", closing, s.text[s.position:])
+}
+
+func unquoteYAML(text string) (string, error) {
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		return strconv.Unquote(text)
+	}
+	return "", fmt.Errorf("not quoted")
+}
+
+// a plain scalar is null, a boolean, a number or else a string
+func plainYAMLScalar(text string) interface{} {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlNumber.MatchString(text) {
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number
+		}
+	}
+	return text
+}
@@ -0,0 +1,119 @@
+package roboticSystem
+
+import (
+	"arrays"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"utils"
+	"vectors"
+)
+
+func TestYAMLSyntax(t *testing.T) {
+	cases := []struct {
+		name, document string
+		expected       interface{}
+	}{
+		{"comments", "# robot\na: 1 # one\nb: 'x # y'\n", map[string]interface{}{"a": 1.0, "b": "x # y"}},
+		{"quoted scalars", `a: "tab\tquote\""` + "\nb: 'it''s'\nc: \"1\"\n", map[string]interface{}{"a": "tab\tquote\"", "b": "it's", "c": "1"}},
+		{"inline lists", "a: [1, -2.5, 3e2]\nb: []\nc: {x: [1], y: z}\n", map[string]interface{}{
+			"a": []interface{}{1.0, -2.5, 300.0}, "b": []interface{}{}, "c": map[string]interface{}{"x": []interface{}{1.0}, "y": "z"}}},
+		{"block lists", "a:\n- 1\n- x: 2\n  y: 3\nb:\n  -\n    - 4\n", map[string]interface{}{
+			"a": []interface{}{1.0, map[string]interface{}{"x": 2.0, "y": 3.0}}, "b": []interface{}{[]interface{}{4.0}}}},
+		{"scalars", "a: ~\nb: true\nc: 0.0.3\nd: 1e\ne: .5\n", map[string]interface{}{"a": nil, "b": true, "c": "0.0.3", "d": "1e", "e": 0.5}},
+	}
+	for _, c := range cases {
+		value, err := parseYAML([]byte(c.document))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		} else if !reflect.DeepEqual(value, c.expected) {
+			t.Errorf("%s: got %#v, expected %#v", c.name, value, c.expected)
+		}
+	}
+}
+
+func TestYAMLSyntaxErrors(t *testing.T) {
+	cases := []struct {
+		name, document, message string
+	}{
+		{"deeper key", "a: 1\n  b: 2\n", "line 2: unexpected indentation"},
+		{"shallower key", "a:\n    b: 1\n  c: 2\n", "line 3: unexpected indentation"},
+		{"misaligned item", "a:\n  - 1\n   - 2\n", "line 3: unexpected indentation"},
+		{"tab indentation", "a:\n\tb: 1\n", "line 2: tabs are not allowed"},
+		{"missing colon", "a: 1\nb\n", "line 2: expected \"key: value\""},
+		{"duplicate key", "a: 1\na: 2\n", "line 2: duplicate key \"a\""},
+		{"multiline list", "a: [1,\n  2]\n", "line 1: unterminated flow collection"},
+		{"unterminated string", "a: \"x\n", "line 1: unterminated string"},
+		{"anchor", "a: &x 1\n", "line 1: unsupported YAML syntax"},
+		{"block scalar", "a: |\n  text\n", "line 1: unsupported YAML syntax"},
+	}
+	for _, c := range cases {
+		_, err := parseYAML([]byte(c.document))
+		if err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("%s: got error %v, expected %q", c.name, err, c.message)
+		}
+	}
+}
+
+func TestYAMLDefinitionErrors(t *testing.T) {
+	cases := []struct {
+		document, field string
+	}{
+		{"links:\n  - joint: revolute\n    d: 0.0.3\n    limits: [0, 1]\n", "links[0].d"},
+		{"links:\n  - joint: revolute\n    d: \"0.03\"\n    limits: [0, 1]\n", "links[0].d"},
+		{"links:\n  - joint: revolute # commented\n    limits: [0, 1, 2] # three\n", "links[0].limits"},
+		{"links:\n  - {joint: revolute, limits: [0, 1]}\n  - {joint: prismatic, limits: [0.2, 0.1]}\n", "links[1].limits"},
+		{"base:\n  position: [0, 0]\nlinks:\n  - {joint: fixed}\n", "base.position"},
+		{"links:\n  - joint: fixed\n    variables:\n      - parameter: 'beta'\n        limits: [0, 1]\n", "links[0].variables[0].parameter"},
+		{"links:\n  - joint: revolute\n    limits: [0, one]\n", "links[0].limits[1]"},
+	}
+	for _, c := range cases {
+		_, err := ParseYAMLSystem([]byte(c.document))
+		if fields := definitionErrorFields(err); !reflect.DeepEqual(fields, []string{c.field}) {
+			t.Errorf("%q: got errors on %v (%v), expected one on %s", c.document, fields, err, c.field)
+		}
+	}
+}
+
+func TestYAMLSystemMatchesGoSystem(t *testing.T) {
+	loaded, err := ParseYAMLSystem([]byte(`
+angles: degrees
+base:
+  position: [1, 0, 0]
+  rpy: [0, 0, 90]
+links:
+  - joint: revolute
+    d: 0.1
+    alpha: 90
+    limits: [-180, 180]
+  - joint: prismatic
+    r: 0.2
+    limits: [0, 0.5]
+  - joint: fixed
+    r: 0.05
+tool:
+  position: [0, 0, 0.02]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	angles := utils.Range1D{LowerBound: -math.Pi, UpperBound: math.Pi}
+	expected := NewSystem(1, 0, 0)
+	expected.BaseOrientation = RotationFromRPY(0, 0, math.Pi/2)
+	expected.AddLink(DHParameters{D: 0.1, Alpha: math.Pi / 2}, angles)
+	expected.AddPrismaticLink(DHParameters{R: 0.2}, utils.Range1D{LowerBound: 0, UpperBound: 0.5})
+	expected.AddLinkWithVariables(DHParameters{R: 0.05})
+	expected.Tool = PoseFromRPY(vectors.NewVector3D(0, 0, 0.02), 0, 0, 0).TransformationMatrix()
+
+	for _, agent := range [][]float64{{0, 0}, {0.7, 0.3}, {-2, 0.5}} {
+		values := arrays.Array1D(agent)
+		a, b := loaded.Copy(), expected.Copy()
+		a.UpdateVariables(&values)
+		b.UpdateVariables(&values)
+		got, want := a.ManipulatorPose(), b.ManipulatorPose()
+		if got.Position.Distance(want.Position) > 1e-12 || OrientationError(got.Orientation, want.Orientation) > 1e-6 {
+			t.Errorf("loaded system at %v is at %s, expected %s", agent, got, want)
+		}
+	}
+}
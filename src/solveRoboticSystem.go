@@ -2,13 +2,16 @@ package main
 
 import (
 	"arrays"
+	"context"
 	de "differentialEvolution"
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
-	"math"
 	"math/rand"
-	"os"
+	"optimizer"
 	"os/exec"
+	"path/filepath"
 	rs "roboticSystem"
 	"runtime"
 	"strings"
@@ -18,15 +21,15 @@ import (
 )
 
 const (
-	PopulationSize  = 10
-	CrossoverRate   = 0.5
-	WeightingFactor = 0.5
-	MaxGenerations  = 2000
-	TargetFitness   = 0.000
-	StallPeriod     = 50  // in generations
-	StallFactor     = 0.0001 // 0~1
+	MaxGenerations = 2000
+	TargetFitness  = 0.000
+	StallPeriod    = 50     // in generations
+	StallFactor    = 0.0001 // 0~1
 	// this can be read as:
 	// if the fitness improvement ratio is less than `StallFactor` for `StallPeriod` times in a row, halt evolution
+	// weights of the position error (in meters) and orientation error (in radians) for pose targets
+	PositionWeight    = 1.0
+	OrientationWeight = 0.1
 )
 
 // https://en.wikipedia.org/wiki/Ackley_function
@@ -40,13 +43,39 @@ const (
 
 func buildFitnessFunction(target vectors.Vector3D, baseSystem rs.System) de.FitnessFunction {
 	return func(agent *arrays.Array1D) float64 {
-		// agent is the theta parameters for each link, one after another
-		// example for n links: `agent = [θ0 θ1 ... θn]`
-		baseSystem.UpdateThetas(agent)
+		// agent is the variables of each link, one after another, see `System.UpdateVariables`
+		// example for n revolute links: `agent = [θ0 θ1 ... θn]`
+		baseSystem.UpdateVariables(agent)
 		return baseSystem.ManipulatorPosition().Distance(target)
 	}
 }
 
+// scores the full pose of the manipulator, so the target is reached from the right angle
+func buildPoseFitnessFunction(target rs.Pose, baseSystem rs.System) de.FitnessFunction {
+	return func(agent *arrays.Array1D) float64 {
+		baseSystem.UpdateVariables(agent)
+		return baseSystem.ManipulatorPose().Error(target, PositionWeight, OrientationWeight)
+	}
+}
+
+// each evolver worker gets its own copy of the system to update
+func buildFitnessFunctionFactory(baseSystem rs.System, build func(rs.System) de.FitnessFunction) de.FitnessFunctionFactory {
+	return func() de.FitnessFunction {
+		return build(baseSystem.Copy())
+	}
+}
+
+// pose of the manipulator at a random joint configuration, so the target is known to be reachable
+func randomReachablePose(rng *rand.Rand, baseSystem rs.System) rs.Pose {
+	system := baseSystem.Copy()
+	values := make(arrays.Array1D, system.VariableCount())
+	for i, space := range system.VariableSpace() {
+		values[i] = utils.RandomInRange(rng, space)
+	}
+	system.UpdateVariables(&values)
+	return system.ManipulatorPose()
+}
+
 //func buildSearchSpace(baseSearchSpace []utils.Range1D, repetitions int) []utils.Range1D {
 //	var searchSpace []utils.Range1D
 //	for i := 0; i < repetitions; i++ {
@@ -57,10 +86,10 @@ func buildFitnessFunction(target vectors.Vector3D, baseSystem rs.System) de.Fitn
 
 func getFileName() string {
 	var filename string
-	if len(os.Args) == 1 {
+	if flag.NArg() == 0 {
 		filename = "example_output.txt"
 	} else {
-		filename = os.Args[1]
+		filename = flag.Arg(0)
 	}
 	return filename
 }
@@ -90,87 +119,88 @@ func runPlottingScript(filename string) {
 }
 
 func main() {
-	rand.Seed(time.Now().Unix())
-	baseSystem := rs.NewSystem(0, 0, 0)
-
-	parameters := []rs.DHParameters{
-		{
-			D:     0.03,
-			R:     0,
-			Alpha: math.Pi / 2.0,
-		},
-		{
-			D:     0,
-			R:     0.1,
-			Alpha: 0,
-		},
-		{
-			D:     0,
-			R:     0.1,
-			Alpha: 0,
-		},
-		{
-			D:     0,
-			R:     0.18,
-			Alpha: 0,
-		},
-	}
-	valueSpaces := []utils.Range1D{
-		{
-			UpperBound: math.Pi,
-		},
-		{
-			UpperBound: math.Pi,
-		},
-		{
-			LowerBound: -math.Pi,
-		},
-		{
-			LowerBound: -math.Pi / 2.0,
-			UpperBound: math.Pi / 2.0,
-		},
+	algorithm := flag.String("algorithm", string(optimizer.DEAlgorithm), fmt.Sprintf("optimization algorithm, one of %v", optimizer.Algorithms))
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed for the random number generator, to reproduce a run")
+	robot := flag.String("robot", "robots/four_links.json", "robot definition file (JSON, YAML with the .yaml or .yml extension, or URDF with the .urdf extension)")
+	tip := flag.String("tip", "", "for URDF robots that branch, the link at the end of the chain to solve for")
+	pose := flag.Bool("pose", false, "target a full pose (position and orientation) instead of a point")
+	flag.Parse()
+	log.Printf("Seed: %d", *seed)
+	rng := utils.NewRand(*seed)
+	var baseSystem rs.System
+	var err error
+	isURDF := strings.EqualFold(filepath.Ext(*robot), ".urdf")
+	if isURDF {
+		baseSystem, err = rs.LoadURDF(*robot, *tip)
+	} else {
+		baseSystem, err = rs.LoadSystem(*robot)
 	}
-	if err := baseSystem.AddLinks(parameters, valueSpaces); err != nil {
-		log.Fatalf("%#v", err)
+	if err != nil {
+		log.Fatal(err)
 	}
-	// Target should have a distance smaller than 0.5 from the base of the system
+	// For the four-link robot, the target should have a distance smaller than 0.5 from the base of the system
 	// Maximum values:
 	//     |x|: x0+0.38
 	//     |y|: y0+0.38
 	//     |z|: z0+0.41
 	// The sum of the coordinates should probably not exceed 0.5
 	//target := vectors.NewVector3D(.1, .1, .1)
-	target := vectors.RandomVector3D(utils.Range1D{
-		LowerBound: -.3,
-		UpperBound:  .3,
+	var target vectors.Vector3D
+	var buildFitness func(rs.System) de.FitnessFunction
+	if *pose {
+		// an arbitrary orientation is rarely reachable, so pick the pose of a random configuration
+		// a pose given directly may use `rs.NewPose`, `rs.PoseFromQuaternion` or `rs.PoseFromRPY`
+		targetPose := randomReachablePose(rng, baseSystem)
+		log.Printf("Target pose: %s", targetPose)
+		target = targetPose.Position
+		buildFitness = func(system rs.System) de.FitnessFunction {
+			return buildPoseFitnessFunction(targetPose, system)
+		}
+	} else {
+		target = vectors.RandomVector3D(rng, utils.Range1D{
+			LowerBound: -.3,
+			UpperBound: .3,
+		})
+		buildFitness = func(system rs.System) de.FitnessFunction {
+			return buildFitnessFunction(target, system)
+		}
+	}
+	// variables at the start, which for URDF robots is their zero configuration
+	initialAgent := baseSystem.Variables()
+	var bestAgentLinkPositions [][]vectors.Vector3D
+	o, err := optimizer.New(optimizer.Algorithm(*algorithm), optimizer.Settings{
+		MaxIterations: MaxGenerations,
+		TargetFitness: TargetFitness,
+		StallPeriod:   StallPeriod,
+		StallFactor:   StallFactor,
+		// start from the current joint configuration
+		InitialAgents: []*arrays.Array1D{initialAgent},
+		Rand:          rng,
+		OnIteration: func(p optimizer.Progress) {
+			baseSystem.UpdateVariables(p.BestAgent)
+			bestAgentLinkPositions = append(bestAgentLinkPositions, baseSystem.LinkPositions())
+			log.Printf("---Generation %d---", p.Iteration)
+			log.Printf("Best agent: %s", p.BestAgent)
+			log.Printf("Pose: %s", baseSystem.ManipulatorPose())
+			log.Printf("Fitness: %.3f", p.BestFitness)
+		},
 	})
-	evolver := de.NewEvolver(de.NewEvolverParams{
-		AgentSize:       baseSystem.Length(),
-		PopulationSize:  PopulationSize,
-		CrossoverRate:   CrossoverRate,
-		WeightingFactor: WeightingFactor,
-		SearchSpace:     baseSystem.GetThetaValueSpace(),
-		MaxGenerations:  MaxGenerations,
-		TargetFitness:   TargetFitness,
-		StallPeriod:     StallPeriod,
-		StallFactor:     StallFactor,
-		FitnessFunction: buildFitnessFunction(target, baseSystem),
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := o.Optimize(context.Background(), optimizer.Problem{
+		SearchSpace:            baseSystem.VariableSpace(),
+		FitnessFunctionFactory: buildFitnessFunctionFactory(baseSystem, buildFitness),
 	})
-	evolver.InitializePopulation()
-	var bestAgentLinkPositions [][]vectors.Vector3D
-	for evolver.ShouldContinue() {
-		err := evolver.Evolve()
-		if err != nil {
-			log.Fatal(err)
-		}
-		baseSystem.UpdateThetas(evolver.CurrentBestAgent)
-		bestAgentLinkPositions = append(bestAgentLinkPositions, baseSystem.LinkPositions())
-		log.Printf("---Generation %d---", evolver.CurrentGeneration)
-		log.Printf("Best agent: %s", evolver.CurrentBestAgent)
-		log.Printf("Position: %s", baseSystem.ManipulatorPosition())
-		log.Printf("Fitness: %.3f", evolver.CurrentBestFitness)
+	if err != nil {
+		log.Fatal(err)
 	}
+	log.Printf("Optimization stopped: %s", result.StopReason)
 	log.Printf("Target was: %s", target.String())
+	log.Printf("Fitness evaluations: %d", result.Evaluations)
+	if isURDF {
+		log.Printf("URDF joint values: %s", rs.URDFJointValues(result.BestAgent, initialAgent))
+	}
 	output := make([]string, len(bestAgentLinkPositions)+1)
 	output[0] = target.String()
 	for i, generation := range bestAgentLinkPositions {
@@ -191,7 +221,7 @@ func main() {
 	//	for t2 := searchSpace[1].LowerBound; t2 <= searchSpace[1].UpperBound; t2 += delta {
 	//		for t3 := searchSpace[2].LowerBound; t3 <= searchSpace[2].UpperBound; t3 += delta {
 	//			for t4 := searchSpace[3].LowerBound; t4 <= searchSpace[3].UpperBound; t4 += delta {
-	//				baseSystem.UpdateThetas(&arrays.Array1D{t1, t2, t3, t4})
+	//				baseSystem.UpdateVariables(&arrays.Array1D{t1, t2, t3, t4})
 	//				positions = append(positions, baseSystem.ManipulatorPosition())
 	//			}
 	//		}
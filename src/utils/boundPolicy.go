@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+)
+
+// BoundPolicy decides how a value that fell outside of a `Range1D` is brought back into it
+type BoundPolicy int
+
+const (
+	// Clamp moves the value to the nearest bound
+	Clamp BoundPolicy = iota
+	// Reflect mirrors the excess back from the violated bound
+	Reflect
+	// Reinitialize picks a new random value in the range
+	Reinitialize
+	// MidpointToParent moves the value halfway between the violated bound and the parent value
+	MidpointToParent
+	// Wrap treats the range as periodic, e.g. a continuous revolute joint; integer ranges repeat
+	// every width + 1, and discrete and categorical ones cannot wrap
+	Wrap
+)
+
+func InRange(value float64, r Range1D) bool {
+	return value >= r.LowerBound && value <= r.UpperBound
+}
+
+// HandleBounds applies the policy of `r` to `value`, if it is out of range,
+// then snaps it to the nearest value the range admits
+// `parent` is the in-range value the out-of-range one was derived from
+func HandleBounds(rng *rand.Rand, value, parent float64, r Range1D) float64 {
+	return r.Snap(bringInRange(rng, value, parent, r))
+}
+
+func bringInRange(rng *rand.Rand, value, parent float64, r Range1D) float64 {
+	if InRange(value, r) {
+		return value
+	}
+	width := r.UpperBound - r.LowerBound
+	switch r.Policy {
+	case Reflect:
+		if width == 0 {
+			return r.LowerBound
+		}
+		// reflecting repeatedly is the same as folding the value over a period of twice the width
+		offset := positiveMod(value-r.LowerBound, 2*width)
+		if offset > width {
+			offset = 2*width - offset
+		}
+		return r.LowerBound + offset
+	case Reinitialize:
+		return RandomInRange(rng, r)
+	case MidpointToParent:
+		if value < r.LowerBound {
+			return (r.LowerBound + ConstrainValue(parent, r)) / 2
+		}
+		return (r.UpperBound + ConstrainValue(parent, r)) / 2
+	case Wrap:
+		if r.Kind == Integer {
+			// the period takes in both bounds, so that e.g. upper bound + 1 wraps to the lower bound
+			low := math.Ceil(r.LowerBound)
+			return low + positiveMod(math.Round(value)-low, math.Floor(r.UpperBound)-low+1)
+		}
+		if width == 0 {
+			return r.LowerBound
+		}
+		return r.LowerBound + positiveMod(value-r.LowerBound, width)
+	default:
+		return ConstrainValue(value, r)
+	}
+}
+
+func positiveMod(x, m float64) float64 {
+	result := math.Mod(x, m)
+	if result < 0 {
+		result += m
+	}
+	return result
+}
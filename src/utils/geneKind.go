@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// GeneKind tells which values within its bounds a `Range1D` admits
+type GeneKind int
+
+const (
+	// Continuous admits any value
+	Continuous GeneKind = iota
+	// Integer admits whole numbers
+	Integer
+	// Discrete admits the values in `Values`, e.g. link lengths from a catalogue
+	Discrete
+	// Categorical admits the indices of `Categories`, which have no order, e.g. a joint type
+	Categorical
+)
+
+func (k GeneKind) String() string {
+	switch k {
+	case Continuous:
+		return "continuous"
+	case Integer:
+		return "integer"
+	case Discrete:
+		return "discrete"
+	case Categorical:
+		return "categorical"
+	}
+	return "unknown"
+}
+
+func IntegerRange(lowerBound, upperBound int) Range1D {
+	return Range1D{
+		LowerBound: float64(lowerBound),
+		UpperBound: float64(upperBound),
+		Kind:       Integer,
+	}
+}
+
+// DiscreteRange admits any of `values`, bounded by the smallest and largest of them
+func DiscreteRange(values ...float64) Range1D {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	r := Range1D{Kind: Discrete, Values: sorted}
+	if len(sorted) > 0 {
+		r.LowerBound, r.UpperBound = sorted[0], sorted[len(sorted)-1]
+	}
+	return r
+}
+
+// CategoricalRange admits the indices of `categories`, from 0 to len(categories)-1
+func CategoricalRange(categories ...string) Range1D {
+	return Range1D{
+		LowerBound: 0,
+		UpperBound: float64(len(categories) - 1),
+		Kind:       Categorical,
+		Categories: categories,
+	}
+}
+
+// Category returns the name of the category `value` encodes
+func (r Range1D) Category(value float64) string {
+	return r.Categories[int(r.Snap(value))]
+}
+
+// Snap returns the admitted value nearest to `value`, which must be within the bounds
+func (r Range1D) Snap(value float64) float64 {
+	switch r.Kind {
+	case Integer, Categorical:
+		return math.Max(math.Ceil(r.LowerBound), math.Min(math.Round(value), math.Floor(r.UpperBound)))
+	case Discrete:
+		i := sort.SearchFloat64s(r.Values, value)
+		if i == len(r.Values) || (i > 0 && value-r.Values[i-1] <= r.Values[i]-value) {
+			i--
+		}
+		return r.Values[i]
+	}
+	return value
+}
+
+// FromUnit maps a value in [0, 1) onto the range, giving every admitted value of
+// integer, discrete and categorical ranges the same share of [0, 1)
+func (r Range1D) FromUnit(unit float64) float64 {
+	switch r.Kind {
+	case Integer, Categorical:
+		low, high := math.Ceil(r.LowerBound), math.Floor(r.UpperBound)
+		return math.Min(low+math.Floor(unit*(high-low+1)), high)
+	case Discrete:
+		return r.Values[int(math.Min(math.Floor(unit*float64(len(r.Values))), float64(len(r.Values)-1)))]
+	}
+	return r.LowerBound + (r.UpperBound-r.LowerBound)*unit
+}
+
+// Validate reports ranges that admit no value, and bound policies the range does not support
+func (r Range1D) Validate() error {
+	switch {
+	case r.Kind == Discrete && len(r.Values) == 0:
+		return fmt.Errorf("discrete range without values")
+	case r.Kind == Categorical && len(r.Categories) == 0:
+		return fmt.Errorf("categorical range without categories")
+	case r.Kind == Integer && math.Ceil(r.LowerBound) > math.Floor(r.UpperBound):
+		return fmt.Errorf("integer range [%v, %v] without integers", r.LowerBound, r.UpperBound)
+	case r.Policy == Wrap && (r.Kind == Discrete || r.Kind == Categorical):
+		return fmt.Errorf("%s range cannot wrap, as its values have no period", r.Kind)
+	case r.LowerBound > r.UpperBound:
+		return fmt.Errorf("range lower bound %v greater than upper bound %v", r.LowerBound, r.UpperBound)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package utils
+
+import "math/rand"
+
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// NewCountingRand is `NewRand` with a source whose state can be saved, see `CountingSource`
+func NewCountingRand(seed int64) (*rand.Rand, *CountingSource) {
+	source := NewCountingSource(seed)
+	return rand.New(source), source
+}
+
+// DeriveRand creates an independent stream seeded from `parent`, for use by a single goroutine
+func DeriveRand(parent *rand.Rand) *rand.Rand {
+	return NewRand(parent.Int63())
+}
+
+// RandState is the state of a `CountingSource`: its seed and the number of values drawn since
+type RandState struct {
+	Seed  int64
+	Draws uint64
+}
+
+// CountingSource draws the same values as `rand.NewSource`, counting them so its state can be
+// saved without drawing from it, and restored by replaying the draws
+type CountingSource struct {
+	source rand.Source64
+	state  RandState
+}
+
+func NewCountingSource(seed int64) *CountingSource {
+	return &CountingSource{
+		source: rand.NewSource(seed).(rand.Source64),
+		state:  RandState{Seed: seed},
+	}
+}
+
+func (s *CountingSource) Int63() int64 {
+	s.state.Draws++
+	return s.source.Int63()
+}
+
+func (s *CountingSource) Uint64() uint64 {
+	s.state.Draws++
+	return s.source.Uint64()
+}
+
+func (s *CountingSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.state = RandState{Seed: seed}
+}
+
+func (s *CountingSource) State() RandState {
+	return s.state
+}
+
+// Restore brings the source back to `state`, replaying its draws
+func (s *CountingSource) Restore(state RandState) {
+	s.Seed(state.Seed)
+	for i := uint64(0); i < state.Draws; i++ {
+		s.source.Uint64()
+	}
+	s.state = state
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCountingSourceDrawsLikeRandSource(t *testing.T) {
+	counting, plain := rand.New(NewCountingSource(7)), rand.New(rand.NewSource(7))
+	for i := 0; i < 100; i++ {
+		if a, b := counting.Float64(), plain.Float64(); a != b {
+			t.Fatalf("draw %d is %v, expected %v as from rand.NewSource", i, a, b)
+		}
+	}
+}
+
+func TestCountingSourceRestore(t *testing.T) {
+	source := NewCountingSource(7)
+	rng := rand.New(source)
+	for i := 0; i < 10; i++ {
+		rng.Float64()
+		rng.Uint64()
+		rng.Intn(5)
+	}
+	state := source.State()
+	if state.Seed != 7 || state.Draws == 0 {
+		t.Fatalf("state %+v, expected seed 7 and some draws", state)
+	}
+	// taking the state must not draw from the source
+	if again := source.State(); again != state {
+		t.Fatalf("state changed from %+v to %+v without drawing", state, again)
+	}
+	expected := make([]float64, 20)
+	for i := range expected {
+		expected[i] = rng.Float64()
+	}
+
+	restored := NewCountingSource(99)
+	restored.Restore(state)
+	if restored.State() != state {
+		t.Errorf("restored state %+v, expected %+v", restored.State(), state)
+	}
+	restoredRng := rand.New(restored)
+	for i, value := range expected {
+		if got := restoredRng.Float64(); got != value {
+			t.Fatalf("draw %d after restoring is %v, expected %v", i, got, value)
+		}
+	}
+}
+
+func TestCountingSourceSeedResetsState(t *testing.T) {
+	source := NewCountingSource(1)
+	source.Int63()
+	source.Seed(3)
+	if state := source.State(); state != (RandState{Seed: 3}) {
+		t.Errorf("state %+v after seeding, expected %+v", state, RandState{Seed: 3})
+	}
+}
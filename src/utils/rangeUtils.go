@@ -9,18 +9,23 @@ import (
 type Range1D struct {
 	LowerBound float64
 	UpperBound float64
+	Policy     BoundPolicy // defaults to `Clamp`
+	Kind       GeneKind    // defaults to `Continuous`
+	Values     []float64   // sorted values admitted by `Discrete` ranges
+	Categories []string    // names of the categories of `Categorical` ranges
 }
 
-func RandomInRange(r Range1D) float64 {
-	return r.LowerBound + (r.UpperBound-r.LowerBound)*rand.Float64()
+// RandomInRange samples uniformly among the values the range admits
+func RandomInRange(rng *rand.Rand, r Range1D) float64 {
+	return r.FromUnit(rng.Float64())
 }
 
-func PickRandom(setSize, howMany, exclude int) *[]int {
+func PickRandom(rng *rand.Rand, setSize, howMany, exclude int) *[]int {
 	alreadyPicked := make([]bool, setSize)
 	picked := make([]int, howMany)
 	for i := range picked {
 		for {
-			pick := rand.Intn(setSize)
+			pick := rng.Intn(setSize)
 			if pick != exclude && !alreadyPicked[pick] {
 				picked[i] = pick
 				alreadyPicked[pick] = true
@@ -31,9 +36,10 @@ func PickRandom(setSize, howMany, exclude int) *[]int {
 	return &picked
 }
 
+// ConstrainValue clamps `value` to the range, then snaps it to the nearest value the range admits
 func ConstrainValue(value float64, constraint Range1D) float64 {
-	return math.Max(constraint.LowerBound,
-		math.Min(value, constraint.UpperBound))
+	return constraint.Snap(math.Max(constraint.LowerBound,
+		math.Min(value, constraint.UpperBound)))
 }
 
 func ConstrainArray(array arrays.Array1D, constraint Range1D) *arrays.Array1D {
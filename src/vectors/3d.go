@@ -4,6 +4,7 @@ import (
 	"arrays"
 	"fmt"
 	"math"
+	"math/rand"
 	"utils"
 )
 
@@ -29,10 +30,10 @@ func NewVector3D(x, y, z float64) Vector3D {
 	}
 }
 
-func RandomVector3D(constraint utils.Range1D) Vector3D {
-	x := utils.RandomInRange(constraint)
-	y := utils.RandomInRange(constraint)
-	z := utils.RandomInRange(constraint)
+func RandomVector3D(rng *rand.Rand, constraint utils.Range1D) Vector3D {
+	x := utils.RandomInRange(rng, constraint)
+	y := utils.RandomInRange(rng, constraint)
+	z := utils.RandomInRange(rng, constraint)
 	return NewVector3D(x, y, z)
 }
 